@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+// sizeUnits are the suffixes accepted by --max-size, largest first.
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+/*
+RunCache manages the results cached with --cache. The parameter args contains
+the executable name, the cache command, its subcommand and the optional flags.
+The only subcommand is prune, which removes the results of other gosystract versions,
+those not used within --max-age and, while the cache exceeds --max-size, the least
+recently used ones.
+
+Example:
+[]string{ "gosystract", "cache", "prune", "--max-age=720h", "--max-size=500MB"}
+
+--cache-dir       Directory of the cache, defaults to the user cache directory.
+
+--max-age         Removes results not used within the duration, e.g. 72h.
+
+--max-size        Removes the least recently used results until the cache fits the size, e.g. 500MB.
+*/
+func RunCache(stdOut io.Writer, stdErr io.Writer, args []string, exit func(int)) {
+	if len(args) < 3 || args[2] != "prune" {
+		showUsage(stdErr, errors.New(invalidSyntaxMessage), exit)
+		return
+	}
+
+	cacheDir, maxAge, maxSize, err := parseCacheValues(args[3:])
+	if err != nil {
+		showUsage(stdErr, err, exit)
+		return
+	}
+
+	cache, err := systract.NewCache(cacheDir)
+	if err == nil {
+		var removed int
+		removed, err = cache.Prune(maxAge, maxSize)
+		printf(stdOut, "%d cached results removed from %s\n", removed, cache.Dir())
+	}
+	if err != nil {
+		printf(stdErr, "\nerror: %s\n", err)
+		exit(1)
+	}
+}
+
+func parseCacheValues(args []string) (cacheDir string, maxAge time.Duration, maxSize int64, err error) {
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--cache-dir="):
+			cacheDir = strings.TrimPrefix(arg, "--cache-dir=")
+		case strings.HasPrefix(arg, "--max-age="):
+			maxAge, err = time.ParseDuration(strings.TrimPrefix(arg, "--max-age="))
+			if err != nil || maxAge < 0 {
+				err = fmt.Errorf("invalid max age: %s", strings.TrimPrefix(arg, "--max-age="))
+				return
+			}
+		case strings.HasPrefix(arg, "--max-size="):
+			maxSize, err = parseSize(strings.TrimPrefix(arg, "--max-size="))
+			if err != nil {
+				return
+			}
+		default:
+			err = fmt.Errorf("invalid flag: %s", arg)
+			return
+		}
+	}
+
+	return
+}
+
+// parseSize parses a size in bytes, optionally suffixed by B, KB, MB or GB.
+func parseSize(value string) (int64, error) {
+	multiplier := int64(1)
+	number := strings.ToUpper(value)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(number, u.suffix) {
+			number, multiplier = strings.TrimSuffix(number, u.suffix), u.bytes
+			break
+		}
+	}
+
+	size, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid max size: %s", value)
+	}
+
+	return size * multiplier, nil
+}
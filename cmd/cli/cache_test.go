@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestRunCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	assertThat := func(assumption string, args []string, expected string, expectedExitCode int) {
+		should := should.New(t)
+		var stdOut, stdErr bytes.Buffer
+		exitCode := 0
+
+		RunCache(&stdOut, &stdErr, args, func(code int) {
+			exitCode = code
+		})
+
+		should.BeEqual(expectedExitCode, exitCode, assumption)
+		should.BeEqual(expected, stdOut.String(), assumption)
+	}
+
+	stale := filepath.Join(dir, "0000000000000000")
+	if err := os.MkdirAll(stale, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(stale, "key.json"), []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	assertThat("should remove results of other versions",
+		[]string{"gosystract", "cache", "prune", "--cache-dir=" + dir},
+		"1 cached results removed from "+dir+"\n", 0)
+	assertThat("should prune by age and size",
+		[]string{"gosystract", "cache", "prune", "--cache-dir=" + dir, "--max-age=24h", "--max-size=500MB"},
+		"0 cached results removed from "+dir+"\n", 0)
+	assertThat("should show usage for unknown subcommands",
+		[]string{"gosystract", "cache", "clear"}, "", 1)
+	assertThat("should show usage without subcommand",
+		[]string{"gosystract", "cache"}, "", 1)
+	assertThat("should error for invalid max age",
+		[]string{"gosystract", "cache", "prune", "--max-age=1week"}, "", 1)
+	assertThat("should error for invalid max size",
+		[]string{"gosystract", "cache", "prune", "--max-size=-1MB"}, "", 1)
+	assertThat("should error for unknown flags",
+		[]string{"gosystract", "cache", "prune", "--dry-run"}, "", 1)
+}
+
+func TestParseCacheValues(t *testing.T) {
+	should := should.New(t)
+
+	dir, maxAge, maxSize, err := parseCacheValues([]string{"--cache-dir=/tmp/cache", "--max-age=72h", "--max-size=2kb"})
+
+	should.NotError(err, "should parse cache flags")
+	should.BeEqual("/tmp/cache", dir, "should parse cache directory")
+	should.BeEqual(72*time.Hour, maxAge, "should parse max age")
+	should.BeEqual(int64(2048), maxSize, "should parse max size")
+}
+
+func TestParseSize(t *testing.T) {
+	assertThat := func(assumption, value string, expected int64, expectedErr bool) {
+		should := should.New(t)
+
+		actual, err := parseSize(value)
+
+		should.BeEqual(expected, actual, assumption)
+		should.BeEqual(expectedErr, err != nil, assumption)
+	}
+
+	assertThat("should parse bytes", "1024", 1024, false)
+	assertThat("should parse bytes with suffix", "1024B", 1024, false)
+	assertThat("should parse kilobytes", "1KB", 1<<10, false)
+	assertThat("should parse megabytes", "500MB", 500<<20, false)
+	assertThat("should parse gigabytes", "2GB", 2<<30, false)
+	assertThat("should error for invalid sizes", "large", 0, true)
+	assertThat("should error for negative sizes", "-1", 0, true)
+}
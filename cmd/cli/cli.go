@@ -2,9 +2,11 @@
 package cli
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -19,10 +21,43 @@ var (
 
 	usageMessage string = `Usage:
 gosystrac [flags] filePath
+gosystrac why [flags] syscall filePath
+gosystrac diff [flags] filePath filePath
+gosystrac check [flags] filePath
+gosystrac update [flags] filePath
+gosystrac index [flags] filePath [-o graphFile]
+gosystrac cache prune [--cache-dir=dir] [--max-age=duration] [--max-size=bytes]
+
+Commands:
+	why               Shows the call chains from the entry points to the syscall name or id.
+	diff              Shows syscalls added and removed between two executables, dumps or json results.
+	                  Exits with code 2 when syscalls were added.
+	check             Checks the syscalls found against the lockfile.
+	                  Exits with code 2 when syscalls not in the lockfile were found.
+	update            Writes the syscalls found into the lockfile.
+	index             Saves the symbol graph parsed, which all commands accept instead of the file.
+	cache prune       Removes cached results of other versions, older than --max-age or beyond --max-size.
 
 Flags:
 	--dumpfile, -d    Handles a dump file instead of a go executable.
+	--objdump         Disassembles the go executable using go tool objdump.
 	--template	  Defines a go template for the results.
+	--output          Defines the output format: text (default), json, packages, sites, seccomp, seccompprofile, dot or graphml.
+	--default-action  Seccomp default action: SCMP_ACT_ERRNO (default), SCMP_ACT_KILL_PROCESS or SCMP_ACT_LOG.
+	--errno           Seccomp errno returned by SCMP_ACT_ERRNO, defaults to 1 (EPERM).
+	--arch            Comma-separated seccomp architectures, defaults to the one detected.
+	--name            SeccompProfile name, defaults to the file name.
+	--namespace       SeccompProfile namespace.
+	--labels          Comma-separated SeccompProfile labels, e.g. app=web,team=a.
+	--lock            Lockfile used by check and update, defaults to syscalls.lock.
+	--syscall-wrapper Additional syscall wrapper, as name[:trap argument position], e.g. pkg.rawSyscall:1.
+	--strict          Exits with code 3 when syscall sites or calls could not be resolved.
+	--bounded-memory  Keeps parsed symbols in a temporary on-disk index, loading each only while walked.
+	--cache           Caches results by go build id, or file checksum, in the user cache directory.
+	--cache-dir       Caches results in the directory provided instead.
+	--no-cache        Disables the cache, overriding --cache and --cache-dir.
+	--collapse-packages Merges the symbols of each package into a single node of dot and graphml outputs.
+	--syscalls        Comma-separated syscall names or ids the dot and graphml outputs are restricted to.
 `
 
 	resultGoTemplate string = `{{if . -}}
@@ -34,34 +69,205 @@ Flags:
 `
 )
 
-func parseInputValues(args []string) (
-	inputIsDumpFile bool, customFormat string, fileName string, err error) {
+const (
+	textOutput           string = "text"
+	jsonOutput           string = "json"
+	packagesOutput       string = "packages"
+	sitesOutput          string = "sites"
+	seccompOutput        string = "seccomp"
+	seccompProfileOutput string = "seccompprofile"
+	dotOutput            string = "dot"
+	graphMLOutput        string = "graphml"
+)
 
+// Exit codes reporting findings, as errors exit with 1.
+const (
+	// syscallsAddedExitCode is returned by diff when new syscalls were found.
+	syscallsAddedExitCode int = 2
+	// lockViolationExitCode is returned by check when syscalls not in the lockfile were found.
+	lockViolationExitCode int = 2
+	// unresolvedExitCode is returned in strict mode when syscall sites or calls could not be resolved.
+	unresolvedExitCode int = 3
+)
+
+type options struct {
+	inputIsDumpFile bool
+	useObjdump      bool
+	customFormat    string
+	fileName        string
+	output          string
+	seccomp         systract.SeccompOptions
+	resource        resourceOptions
+	lockFile        string
+	strict          bool
+	boundedMemory   bool
+	cache           *systract.Cache
+	graph           systract.GraphOptions
+	syscallWrappers []systract.SyscallWrapper
+}
+
+func parseInputValues(args []string) (opts options, err error) {
 	if len(args) < 2 {
 		err = errors.New(invalidSyntaxMessage)
 		return
 	}
 
-	fileName = args[len(args)-1]
-	for _, arg := range args[1:] {
+	opts.fileName = args[len(args)-1]
+	opts.output = textOutput
+	opts.lockFile = defaultLockFile
+	useCache, noCache, cacheDir := false, false, ""
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
 		if arg == "--dumpfile" || arg == "-d" {
-			inputIsDumpFile = true
+			opts.inputIsDumpFile = true
+			continue
+		}
+
+		if arg == "--objdump" {
+			opts.useObjdump = true
+			continue
+		}
+
+		if arg == "--strict" {
+			opts.strict = true
+			continue
+		}
+
+		if arg == "--bounded-memory" {
+			opts.boundedMemory = true
+			continue
+		}
+
+		if arg == "--cache" {
+			useCache = true
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--cache-dir=") {
+			useCache = true
+			cacheDir = strings.TrimPrefix(arg, "--cache-dir=")
+			continue
+		}
+
+		if arg == "--no-cache" {
+			noCache = true
+			continue
+		}
+
+		if arg == "--collapse-packages" {
+			opts.graph.CollapsePackages = true
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--syscalls=") {
+			opts.graph.Syscalls = strings.Split(strings.TrimPrefix(arg, "--syscalls="), ",")
 			continue
 		}
 
 		if strings.HasPrefix(arg, "--template=") {
-			customFormat = strings.TrimPrefix(arg, "--template=")
+			opts.customFormat = strings.TrimPrefix(arg, "--template=")
+
+			if strings.HasPrefix(opts.customFormat, "\"") {
+				opts.customFormat = strings.TrimPrefix(opts.customFormat, "\"")
+			}
+
+			if strings.HasSuffix(opts.customFormat, "\"") {
+				opts.customFormat = strings.TrimSuffix(opts.customFormat, "\"")
+			}
+
+			continue
+		}
 
-			if strings.HasPrefix(customFormat, "\"") {
-				customFormat = strings.TrimPrefix(customFormat, "\"")
+		if strings.HasPrefix(arg, "--output=") {
+			opts.output = strings.TrimPrefix(arg, "--output=")
+			if !isValidOutput(opts.output) {
+				err = fmt.Errorf("invalid output: %s", opts.output)
+				return
 			}
+			continue
+		}
 
-			if strings.HasSuffix(customFormat, "\"") {
-				customFormat = strings.TrimSuffix(customFormat, "\"")
+		if strings.HasPrefix(arg, "--default-action=") {
+			opts.seccomp.DefaultAction = strings.TrimPrefix(arg, "--default-action=")
+			if !isValidDefaultAction(opts.seccomp.DefaultAction) {
+				err = fmt.Errorf("unsupported default action: %s", opts.seccomp.DefaultAction)
+				return
 			}
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--errno=") {
+			errno, e := strconv.ParseUint(strings.TrimPrefix(arg, "--errno="), 10, 32)
+			if e != nil {
+				err = errors.New("invalid errno")
+				return
+			}
+			opts.seccomp.ErrnoRet = uint(errno)
+			continue
+		}
 
+		if strings.HasPrefix(arg, "--arch=") {
+			opts.seccomp.Architectures = strings.Split(strings.TrimPrefix(arg, "--arch="), ",")
 			continue
 		}
+
+		if strings.HasPrefix(arg, "--name=") {
+			opts.resource.name = strings.TrimPrefix(arg, "--name=")
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--namespace=") {
+			opts.resource.namespace = strings.TrimPrefix(arg, "--namespace=")
+			continue
+		}
+
+		if arg == "--lock" {
+			if i+2 >= len(args) {
+				err = errors.New("--lock requires a lockfile followed by the input file")
+				return
+			}
+			i++
+			opts.lockFile = args[i]
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--lock=") {
+			opts.lockFile = strings.TrimPrefix(arg, "--lock=")
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--syscall-wrapper=") {
+			wrapper, e := parseSyscallWrapper(strings.TrimPrefix(arg, "--syscall-wrapper="))
+			if e != nil {
+				err = e
+				return
+			}
+			opts.syscallWrappers = append(opts.syscallWrappers, wrapper)
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--labels=") {
+			opts.resource.labels, err = parseLabels(strings.TrimPrefix(arg, "--labels="))
+			if err != nil {
+				return
+			}
+			continue
+		}
+	}
+
+	// SeccompProfile resources have no field for the errno returned by their default action.
+	if opts.seccomp.ErrnoRet != 0 && opts.output == seccompProfileOutput {
+		err = errors.New("--errno is not supported by the seccompprofile output")
+		return
+	}
+
+	if (opts.graph.CollapsePackages || opts.graph.Syscalls != nil) && !isGraphOutput(opts.output) {
+		err = errors.New("--collapse-packages and --syscalls require the dot or graphml output")
+		return
+	}
+
+	if useCache && !noCache {
+		opts.cache, err = systract.NewCache(cacheDir)
 	}
 
 	return
@@ -78,39 +284,167 @@ Flag options:
 
 --dumpfile, -d    Handles a dump file instead of go executable.
 
+--objdump         Disassembles the go executable using go tool objdump.
+
 --template        Defines a go template for the results.
+
+--output          Defines the output format: text (default), json, packages, sites, seccomp, seccompprofile, dot or graphml.
+
+--default-action  Seccomp default action: SCMP_ACT_ERRNO (default), SCMP_ACT_KILL_PROCESS or SCMP_ACT_LOG.
+
+--errno           Seccomp errno returned by SCMP_ACT_ERRNO, defaults to 1 (EPERM).
+
+--arch            Comma-separated seccomp architectures, defaults to the one detected.
+
+--name            SeccompProfile name, defaults to the file name.
+
+--namespace       SeccompProfile namespace.
+
+--labels          Comma-separated SeccompProfile labels, e.g. app=web,team=a.
+
+--lock            Lockfile used by check and update, defaults to syscalls.lock.
+
+--syscall-wrapper Additional syscall wrapper, as name[:trap argument position], e.g. pkg.rawSyscall:1.
+
+--strict          Exits with code 3 when syscall sites or calls could not be resolved.
+
+--bounded-memory  Keeps parsed symbols in a temporary on-disk index, loading each only while walked.
+
+--cache           Caches results by go build id, or file checksum, in the user cache directory.
+
+--cache-dir       Caches results in the directory provided instead.
+
+--no-cache        Disables the cache, overriding --cache and --cache-dir.
+
+--collapse-packages Merges the symbols of each package into a single node of dot and graphml outputs.
+
+--syscalls        Comma-separated syscall names or ids the dot and graphml outputs are restricted to.
 */
-func Run(stdOut io.Writer, stdErr io.Writer, args []string, extract func(source systract.SourceReader) ([]systract.SystemCall, error),
-	exit func(int)) {
+func Run(stdOut io.Writer, stdErr io.Writer, args []string, analyse func(source systract.SourceReader) (*systract.Result, error),
+	export func(source systract.SourceReader, opts systract.GraphOptions) (*systract.Graph, error), exit func(int)) {
 
-	inputIsDumpFile, customFormat, fileName, err := parseInputValues(args)
+	opts, err := parseInputValues(args)
 	if err != nil {
-		usage := fmt.Sprintf("gosystract version %s\n%s", gitcommit, usageMessage)
-		printf(stdErr, usage)
-		printf(stdErr, fmt.Sprintf("\nerror: %s\n", errors.New(invalidSyntaxMessage)))
-		exit(1)
+		showUsage(stdErr, err, exit)
 		return
 	}
 
-	var sourceReader systract.SourceReader
-	if inputIsDumpFile {
-		sourceReader = systract.NewDumpReader(fileName)
-	} else {
-		sourceReader = systract.NewExeReader(fileName)
+	if isGraphOutput(opts.output) {
+		exportGraph(stdOut, stdErr, opts, export, exit)
+		return
 	}
 
-	syscalls, err := extract(sourceReader)
+	result, err := analyse(getSourceReader(opts))
 	if err != nil {
-		printf(stdErr, fmt.Sprintf("\nerror: %s\n", err))
+		printf(stdErr, "\nerror: %s\n", err)
 		exit(1)
 		return
 	}
 
-	err = writeResults(stdOut, syscalls, customFormat)
+	switch opts.output {
+	case jsonOutput:
+		err = writeJSON(stdOut, result)
+	case packagesOutput:
+		writePackages(stdOut, result.Packages)
+	case sitesOutput:
+		writeSites(stdOut, result)
+	case seccompOutput:
+		err = writeSeccompProfile(stdOut, result, opts.seccomp)
+	case seccompProfileOutput:
+		if opts.resource.name == "" {
+			opts.resource.name = resourceName(opts.fileName)
+		}
+		err = writeSeccompProfileResource(stdOut, result, opts.seccomp, opts.resource)
+	default:
+		err = writeResults(stdOut, result.SystemCalls, opts.customFormat)
+	}
 	if err != nil {
-		printf(stdErr, fmt.Sprintf("\nerror: %s\n", err))
+		printf(stdErr, "\nerror: %s\n", err)
 		exit(1)
+		return
+	}
+
+	if opts.strict {
+		failOnUnresolved(stdErr, result, exit)
+	}
+}
+
+// parseSyscallWrapper parses a syscall wrapper defined as name[:trapArg],
+// the trap argument defaulting to the first one.
+func parseSyscallWrapper(value string) (systract.SyscallWrapper, error) {
+	wrapper := systract.SyscallWrapper{Name: value}
+	if i := strings.LastIndex(value, ":"); i >= 0 {
+		n, err := strconv.Atoi(value[i+1:])
+		if err != nil || n < 0 {
+			return systract.SyscallWrapper{}, fmt.Errorf("invalid syscall wrapper: %s", value)
+		}
+		wrapper.Name, wrapper.TrapArg = value[:i], n
+	}
+	if wrapper.Name == "" {
+		return systract.SyscallWrapper{}, fmt.Errorf("invalid syscall wrapper: %s", value)
+	}
+
+	return wrapper, nil
+}
+
+func isValidOutput(output string) bool {
+	switch output {
+	case textOutput, jsonOutput, packagesOutput, sitesOutput, seccompOutput, seccompProfileOutput, dotOutput, graphMLOutput:
+		return true
+	}
+
+	return false
+}
+
+func isGraphOutput(output string) bool {
+	return output == dotOutput || output == graphMLOutput
+}
+
+func isValidDefaultAction(action string) bool {
+	switch action {
+	case systract.SeccompActErrno, systract.SeccompActKillProcess, systract.SeccompActLog:
+		return true
+	}
+
+	return false
+}
+
+func showUsage(stdErr io.Writer, err error, exit func(int)) {
+	usage := fmt.Sprintf("gosystract version %s\n%s", gitcommit, usageMessage)
+	printf(stdErr, "%s", usage)
+	printf(stdErr, "\nerror: %s\n", err)
+	exit(1)
+}
+
+// getSourceReader returns the reader of the file, registering the syscall wrappers
+// provided so that they are handled when it is analysed.
+func getSourceReader(opts options) systract.SourceReader {
+	source := getFileSourceReader(opts)
+	if len(opts.syscallWrappers) > 0 {
+		source = systract.NewWrapperSource(source, opts.syscallWrappers)
 	}
+	if opts.boundedMemory {
+		source = systract.NewBoundedSource(source, "")
+	}
+	if opts.cache != nil {
+		source = systract.NewCachedSource(source, opts.cache)
+	}
+
+	return source
+}
+
+func getFileSourceReader(opts options) systract.SourceReader {
+	if systract.IsGraphFile(opts.fileName) {
+		return systract.NewGraphReader(opts.fileName)
+	}
+	if opts.inputIsDumpFile {
+		return systract.NewDumpReader(opts.fileName)
+	}
+	if opts.useObjdump {
+		return systract.NewExeReader(opts.fileName)
+	}
+
+	return systract.NewELFReader(opts.fileName)
 }
 
 func writeResults(output io.Writer, syscalls []systract.SystemCall, customFormat string) (err error) {
@@ -129,6 +463,35 @@ func writeResults(output io.Writer, syscalls []systract.SystemCall, customFormat
 	return
 }
 
+func writeSeccompProfile(output io.Writer, result *systract.Result, opts systract.SeccompOptions) error {
+	profile, err := newSeccompProfile(result, opts)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(output, profile)
+}
+
+func writeJSON(output io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// newSeccompProfile generates the seccomp profile for the result,
+// defaulting to the architecture detected when none is set.
+func newSeccompProfile(result *systract.Result, opts systract.SeccompOptions) (*systract.SeccompProfile, error) {
+	if len(opts.Architectures) == 0 {
+		arch, err := systract.SeccompArch(result.Arch)
+		if err != nil {
+			return nil, err
+		}
+		opts.Architectures = []string{arch}
+	}
+
+	return systract.NewSeccompProfile(result.SystemCalls, opts)
+}
+
 func recoverError(err *error) {
 	if e := recover(); e != nil {
 		*err = errors.New("invalid go template")
@@ -14,15 +14,54 @@ func TestParseInputValues(t *testing.T) {
 	assertThat := func(assumption string, args []string, expected string) {
 		should := should.New(t)
 
-		_, actual, _, err := parseInputValues(args)
+		opts, err := parseInputValues(args)
 
 		should.NotError(err, assumption)
-		should.BeEqual(expected, actual, assumption)
+		should.BeEqual(expected, opts.customFormat, assumption)
 	}
 
 	assertThat("should handle template flag", []string{"gosystract", "--template=\"test\"", ""}, "test")
 }
 
+func TestParseInputValues_Resource(t *testing.T) {
+	should := should.New(t)
+
+	opts, err := parseInputValues([]string{"gosystract", "--output=seccompprofile", "--name=app", "--namespace=prod",
+		"--labels=team=a,app=web", "filename"})
+
+	should.NotError(err, "should parse resource flags")
+	should.BeEqual(seccompProfileOutput, opts.output, "should parse seccompprofile output")
+	should.BeEqual(resourceOptions{name: "app", namespace: "prod", labels: []label{{"app", "web"}, {"team", "a"}}},
+		opts.resource, "should parse resource flags")
+}
+
+func TestParseInputValues_Seccomp(t *testing.T) {
+	assertThat := func(assumption string, args []string, expectedOutput string, expected systract.SeccompOptions, expectedErr bool) {
+		should := should.New(t)
+
+		opts, err := parseInputValues(args)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		if !expectedErr {
+			should.BeEqual(expectedOutput, opts.output, assumption)
+			should.BeEqual(expected, opts.seccomp, assumption)
+		}
+	}
+
+	assertThat("should default to text output", []string{"gosystract", "filename"}, "text", systract.SeccompOptions{}, false)
+	assertThat("should handle seccomp flags",
+		[]string{"gosystract", "--output=seccomp", "--default-action=SCMP_ACT_ERRNO", "--errno=38", "--arch=SCMP_ARCH_AARCH64", "filename"},
+		"seccomp", systract.SeccompOptions{DefaultAction: "SCMP_ACT_ERRNO", ErrnoRet: 38, Architectures: []string{"SCMP_ARCH_AARCH64"}}, false)
+	assertThat("should error for unknown outputs", []string{"gosystract", "--output=xml", "filename"}, "", systract.SeccompOptions{}, true)
+	assertThat("should error for invalid labels", []string{"gosystract", "--labels=app", "filename"}, "", systract.SeccompOptions{}, true)
+	assertThat("should error for invalid errno", []string{"gosystract", "--errno=abc", "filename"}, "", systract.SeccompOptions{}, true)
+	assertThat("should error for errno with seccompprofile output", []string{"gosystract", "--output=seccompprofile", "--errno=38", "filename"},
+		"", systract.SeccompOptions{}, true)
+	assertThat("should error for unsupported default actions", []string{"gosystract", "--default-action=SCMP_ACT_TRAP", "filename"},
+		"", systract.SeccompOptions{}, true)
+}
+
 func TestRun(t *testing.T) {
 	assertThat := func(assumption string, args []string,
 		stub func() ([]systract.SystemCall, error), expected string,
@@ -33,9 +72,13 @@ func TestRun(t *testing.T) {
 		var stdOut, stdErr bytes.Buffer
 		var hasErrored bool
 
-		Run(&stdOut, &stdErr, args, func(source systract.SourceReader) ([]systract.SystemCall, error) {
-			return stub()
-		}, func(code int) {
+		Run(&stdOut, &stdErr, args, func(source systract.SourceReader) (*systract.Result, error) {
+			syscalls, err := stub()
+			if err != nil {
+				return nil, err
+			}
+			return &systract.Result{Arch: "amd64", SystemCalls: syscalls}, nil
+		}, unexpectedExport(t), func(code int) {
 			hasErrored = true
 		})
 
@@ -54,10 +97,43 @@ func TestRun(t *testing.T) {
 		`gosystract version TESTVERSION
 Usage:
 gosystrac [flags] filePath
+gosystrac why [flags] syscall filePath
+gosystrac diff [flags] filePath filePath
+gosystrac check [flags] filePath
+gosystrac update [flags] filePath
+gosystrac index [flags] filePath [-o graphFile]
+gosystrac cache prune [--cache-dir=dir] [--max-age=duration] [--max-size=bytes]
+
+Commands:
+	why               Shows the call chains from the entry points to the syscall name or id.
+	diff              Shows syscalls added and removed between two executables, dumps or json results.
+	                  Exits with code 2 when syscalls were added.
+	check             Checks the syscalls found against the lockfile.
+	                  Exits with code 2 when syscalls not in the lockfile were found.
+	update            Writes the syscalls found into the lockfile.
+	index             Saves the symbol graph parsed, which all commands accept instead of the file.
+	cache prune       Removes cached results of other versions, older than --max-age or beyond --max-size.
 
 Flags:
 	--dumpfile, -d    Handles a dump file instead of a go executable.
+	--objdump         Disassembles the go executable using go tool objdump.
 	--template	  Defines a go template for the results.
+	--output          Defines the output format: text (default), json, packages, sites, seccomp, seccompprofile, dot or graphml.
+	--default-action  Seccomp default action: SCMP_ACT_ERRNO (default), SCMP_ACT_KILL_PROCESS or SCMP_ACT_LOG.
+	--errno           Seccomp errno returned by SCMP_ACT_ERRNO, defaults to 1 (EPERM).
+	--arch            Comma-separated seccomp architectures, defaults to the one detected.
+	--name            SeccompProfile name, defaults to the file name.
+	--namespace       SeccompProfile namespace.
+	--labels          Comma-separated SeccompProfile labels, e.g. app=web,team=a.
+	--lock            Lockfile used by check and update, defaults to syscalls.lock.
+	--syscall-wrapper Additional syscall wrapper, as name[:trap argument position], e.g. pkg.rawSyscall:1.
+	--strict          Exits with code 3 when syscall sites or calls could not be resolved.
+	--bounded-memory  Keeps parsed symbols in a temporary on-disk index, loading each only while walked.
+	--cache           Caches results by go build id, or file checksum, in the user cache directory.
+	--cache-dir       Caches results in the directory provided instead.
+	--no-cache        Disables the cache, overriding --cache and --cache-dir.
+	--collapse-packages Merges the symbols of each package into a single node of dot and graphml outputs.
+	--syscalls        Comma-separated syscall names or ids the dot and graphml outputs are restricted to.
 
 error: invalid syntax
 `)
@@ -112,6 +188,80 @@ error: invalid syntax
 		},
 		"",
 		true, "\nerror: invalid go template\n")
+
+	assertThat("should write results as json",
+		[]string{"gosystract", "--output=json", "filename"},
+		func() ([]systract.SystemCall, error) {
+			return []systract.SystemCall{{ID: 1, Name: "write"}}, nil
+		},
+		"{\n  \"arch\": \"amd64\",\n  \"systemCalls\": [\n    {\n      \"id\": 1,\n      \"name\": \"write\"\n    }\n  ]\n}\n", false, "")
+
+	assertThat("should generate seccomp profiles for the detected architecture",
+		[]string{"gosystract", "--output=seccomp", "filename"},
+		func() ([]systract.SystemCall, error) {
+			return []systract.SystemCall{{ID: 1, Name: "write"}, {ID: 231, Name: "exit_group"}}, nil
+		},
+		`{
+  "defaultAction": "SCMP_ACT_ERRNO",
+  "defaultErrnoRet": 1,
+  "architectures": [
+    "SCMP_ARCH_X86_64"
+  ],
+  "syscalls": [
+    {
+      "names": [
+        "exit_group",
+        "write"
+      ],
+      "action": "SCMP_ACT_ALLOW"
+    }
+  ]
+}
+`, false, "")
+
+	assertThat("should support seccomp options",
+		[]string{"gosystract", "--output=seccomp", "--default-action=SCMP_ACT_LOG", "--arch=SCMP_ARCH_X86_64,SCMP_ARCH_X86", "filename"},
+		func() ([]systract.SystemCall, error) {
+			return []systract.SystemCall{{ID: 1, Name: "write"}}, nil
+		},
+		`{
+  "defaultAction": "SCMP_ACT_LOG",
+  "architectures": [
+    "SCMP_ARCH_X86_64",
+    "SCMP_ARCH_X86"
+  ],
+  "syscalls": [
+    {
+      "names": [
+        "write"
+      ],
+      "action": "SCMP_ACT_ALLOW"
+    }
+  ]
+}
+`, false, "")
+
+	assertThat("should generate SeccompProfile resources named after the file",
+		[]string{"gosystract", "--output=seccompprofile", "--namespace=prod", "--labels=app=web", "/bin/My_App"},
+		func() ([]systract.SystemCall, error) {
+			return []systract.SystemCall{{ID: 1, Name: "write"}}, nil
+		},
+		`apiVersion: security-profiles-operator.x-k8s.io/v1beta1
+kind: SeccompProfile
+metadata:
+  name: "my-app"
+  namespace: "prod"
+  labels:
+    "app": "web"
+spec:
+  defaultAction: "SCMP_ACT_ERRNO"
+  architectures:
+  - "SCMP_ARCH_X86_64"
+  syscalls:
+  - action: "SCMP_ACT_ALLOW"
+    names:
+    - "write"
+`, false, "")
 }
 
 func TestRun_SourceReaders(t *testing.T) {
@@ -120,10 +270,10 @@ func TestRun_SourceReaders(t *testing.T) {
 		var stdOut, stdErr bytes.Buffer
 		var hasErrored bool
 
-		Run(&stdOut, &stdErr, args, func(source systract.SourceReader) ([]systract.SystemCall, error) {
+		Run(&stdOut, &stdErr, args, func(source systract.SourceReader) (*systract.Result, error) {
 			should.HaveSameType(expected, source, "should be able to handle dump files")
-			return []systract.SystemCall{}, nil
-		}, func(code int) {
+			return &systract.Result{}, nil
+		}, unexpectedExport(t), func(code int) {
 			hasErrored = true
 		})
 
@@ -132,8 +282,57 @@ func TestRun_SourceReaders(t *testing.T) {
 
 	assertThat("should be able to handle exec files",
 		[]string{"gosystract", "filename"},
+		&systract.ELFReader{})
+	assertThat("should be able to handle exec files through go tool objdump",
+		[]string{"gosystract", "--objdump", "filename"},
 		&systract.ExeReader{})
 	assertThat("should be able to handle dump files",
 		[]string{"gosystract", "--dumpfile", "filename"},
 		&systract.DumpReader{})
+	assertThat("should be able to analyse sources in bounded memory",
+		[]string{"gosystract", "--bounded-memory", "--dumpfile", "filename"},
+		&systract.BoundedSource{})
+	assertThat("should be able to cache results",
+		[]string{"gosystract", "--cache", "filename"},
+		&systract.CachedSource{})
+	assertThat("should be able to cache results in a custom directory",
+		[]string{"gosystract", "--cache-dir=/tmp/cache", "--bounded-memory", "filename"},
+		&systract.CachedSource{})
+	assertThat("should be able to handle symbol graphs",
+		[]string{"gosystract", "../../test/single-syscall.sgx"},
+		&systract.GraphReader{})
+	assertThat("should not cache results when disabled",
+		[]string{"gosystract", "--no-cache", "--cache-dir=/tmp/cache", "filename"},
+		&systract.ELFReader{})
+}
+
+func TestParseSyscallWrapper(t *testing.T) {
+	assertThat := func(assumption, value string, expected systract.SyscallWrapper, expectedErr bool) {
+		should := should.New(t)
+
+		actual, err := parseSyscallWrapper(value)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should use first argument by default", "example.com/pkg/sys.do",
+		systract.SyscallWrapper{Name: "example.com/pkg/sys.do"}, false)
+	assertThat("should parse trap argument", "example.com/pkg/sys.do:1",
+		systract.SyscallWrapper{Name: "example.com/pkg/sys.do", TrapArg: 1}, false)
+	assertThat("should error for invalid trap argument", "example.com/pkg/sys.do:a", systract.SyscallWrapper{}, true)
+	assertThat("should error for negative trap argument", "example.com/pkg/sys.do:-1", systract.SyscallWrapper{}, true)
+	assertThat("should error for empty names", ":1", systract.SyscallWrapper{}, true)
+}
+
+func TestParseInputValues_SyscallWrappers(t *testing.T) {
+	should := should.New(t)
+
+	opts, err := parseInputValues([]string{"gosystract", "--syscall-wrapper=example.com/pkg/sys.do",
+		"--syscall-wrapper=example.com/pkg/sys.do6:1", "filename"})
+
+	should.NotError(err, "should parse syscall wrappers")
+	should.BeEqual([]systract.SyscallWrapper{{Name: "example.com/pkg/sys.do"}, {Name: "example.com/pkg/sys.do6", TrapArg: 1}},
+		opts.syscallWrappers, "should carry syscall wrappers in options")
 }
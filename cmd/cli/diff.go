@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+/*
+RunDiff writes the syscalls added and removed between two sources, which can be
+executables, dump files or results previously saved with --output=json.
+The parameter args contains the executable name, the diff command, the optional flags
+followed by the base and the target filepaths.
+
+Example:
+[]string{ "gosystract", "diff", "--output=json", "v1.json", "v2"}
+*/
+func RunDiff(stdOut io.Writer, stdErr io.Writer, args []string,
+	analyse func(source systract.SourceReader) (*systract.Result, error), exit func(int)) {
+
+	if len(args) < 4 {
+		showUsage(stdErr, errors.New(invalidSyntaxMessage), exit)
+		return
+	}
+
+	opts, err := parseInputValues(append([]string{args[0]}, args[2:]...))
+	if err == nil && opts.output != textOutput && opts.output != jsonOutput {
+		err = fmt.Errorf("invalid output: %s", opts.output)
+	}
+	if err != nil {
+		showUsage(stdErr, err, exit)
+		return
+	}
+
+	base, err := loadResult(args[len(args)-2], opts, analyse)
+	if err != nil {
+		printf(stdErr, "\nerror: %s\n", err)
+		exit(1)
+		return
+	}
+
+	target, err := loadResult(args[len(args)-1], opts, analyse)
+	if err != nil {
+		printf(stdErr, "\nerror: %s\n", err)
+		exit(1)
+		return
+	}
+
+	diff := systract.Diff(base.SystemCalls, target.SystemCalls)
+	if opts.output == jsonOutput {
+		err = writeJSON(stdOut, diff)
+	} else {
+		writeDiff(stdOut, diff)
+	}
+	if err != nil {
+		printf(stdErr, "\nerror: %s\n", err)
+		exit(1)
+		return
+	}
+
+	if diff.HasAdditions() {
+		exit(syscallsAddedExitCode)
+	}
+}
+
+// loadResult loads saved results as is, analysing all other files.
+func loadResult(fileName string, opts options,
+	analyse func(source systract.SourceReader) (*systract.Result, error)) (*systract.Result, error) {
+
+	if result, err := systract.LoadResult(fileName); err == nil {
+		return result, nil
+	}
+
+	opts.fileName = fileName
+	return analyse(getSourceReader(opts))
+}
+
+func writeDiff(output io.Writer, diff systract.DiffResult) {
+	if len(diff.Added) == 0 && len(diff.Removed) == 0 {
+		printf(output, "no system call changes were found\n")
+		return
+	}
+
+	if len(diff.Added) > 0 {
+		printf(output, "%d system calls added:\n", len(diff.Added))
+		for _, s := range diff.Added {
+			printf(output, "    + %s (%d)\n", s.Name, s.ID)
+		}
+	}
+
+	if len(diff.Removed) > 0 {
+		printf(output, "%d system calls removed:\n", len(diff.Removed))
+		for _, s := range diff.Removed {
+			printf(output, "    - %s (%d)\n", s.Name, s.ID)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+func TestRunDiff(t *testing.T) {
+	saved, err := ioutil.TempFile("", "result.*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(saved.Name())
+	saved.WriteString(`{"arch":"amd64","systemCalls":[{"id":0,"name":"read"},{"id":1,"name":"write"}]}`)
+	saved.Close()
+
+	assertThat := func(assumption string, args []string, analysed map[string][]systract.SystemCall,
+		expected string, expectedExitCode int, expectedErr string) {
+
+		should := should.New(t)
+		var stdOut, stdErr bytes.Buffer
+		exitCode := 0
+
+		RunDiff(&stdOut, &stdErr, args, func(source systract.SourceReader) (*systract.Result, error) {
+			for name, syscalls := range analysed {
+				if *source.(*systract.DumpReader) == *systract.NewDumpReader(name) {
+					return &systract.Result{Arch: "amd64", SystemCalls: syscalls}, nil
+				}
+			}
+			return nil, errors.New("could not extract syscalls")
+		}, func(code int) {
+			exitCode = code
+		})
+
+		should.BeEqual(expectedExitCode, exitCode, assumption)
+		should.BeEqual(expected, stdOut.String(), assumption)
+		if expectedErr != "" {
+			should.BeEqual(expectedErr, stdErr.String(), assumption)
+		}
+	}
+
+	read := systract.SystemCall{ID: 0, Name: "read"}
+	write := systract.SystemCall{ID: 1, Name: "write"}
+	ptrace := systract.SystemCall{ID: 101, Name: "ptrace"}
+
+	assertThat("should show no changes",
+		[]string{"gosystract", "diff", "-d", "a", "b"},
+		map[string][]systract.SystemCall{"a": {read}, "b": {read}},
+		"no system call changes were found\n", 0, "")
+
+	assertThat("should show added and removed syscalls, exiting with code 2",
+		[]string{"gosystract", "diff", "-d", "a", "b"},
+		map[string][]systract.SystemCall{"a": {read, write}, "b": {read, ptrace}},
+		"1 system calls added:\n    + ptrace (101)\n1 system calls removed:\n    - write (1)\n", 2, "")
+
+	assertThat("should not exit with error when syscalls are only removed",
+		[]string{"gosystract", "diff", "-d", "a", "b"},
+		map[string][]systract.SystemCall{"a": {read, write}, "b": {read}},
+		"1 system calls removed:\n    - write (1)\n", 0, "")
+
+	assertThat("should compare against saved results as json",
+		[]string{"gosystract", "diff", "--output=json", "-d", saved.Name(), "b"},
+		map[string][]systract.SystemCall{"b": {read, ptrace}},
+		"{\n  \"added\": [\n    {\n      \"id\": 101,\n      \"name\": \"ptrace\"\n    }\n  ],\n"+
+			"  \"removed\": [\n    {\n      \"id\": 1,\n      \"name\": \"write\"\n    }\n  ]\n}\n", 2, "")
+
+	assertThat("should error when a source cannot be analysed",
+		[]string{"gosystract", "diff", "-d", "a", "b"},
+		map[string][]systract.SystemCall{"a": {read}},
+		"", 1, "\nerror: could not extract syscalls\n")
+
+	assertThat("should error for outputs not supported by diff",
+		[]string{"gosystract", "diff", "--output=seccomp", "-d", "a", "b"},
+		map[string][]systract.SystemCall{},
+		"", 1, "")
+
+	assertThat("should show usage when a file is missing",
+		[]string{"gosystract", "diff", "a"},
+		map[string][]systract.SystemCall{},
+		"", 1, "")
+}
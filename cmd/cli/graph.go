@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+const (
+	// directColour fills nodes issuing system calls directly.
+	directColour string = "#f4a6a6"
+	// indirectColour fills nodes only reaching system calls through their callees.
+	indirectColour string = "#ffffff"
+)
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// exportGraph writes the call graph of the source in the dot or graphml output format.
+func exportGraph(stdOut io.Writer, stdErr io.Writer, opts options,
+	export func(source systract.SourceReader, opts systract.GraphOptions) (*systract.Graph, error), exit func(int)) {
+	graph, err := export(getSourceReader(opts), opts.graph)
+	if err == nil {
+		if opts.output == graphMLOutput {
+			err = writeGraphML(stdOut, graph)
+		} else {
+			writeDot(stdOut, graph)
+		}
+	}
+	if err != nil {
+		printf(stdErr, "\nerror: %s\n", err)
+		exit(1)
+	}
+}
+
+// writeDot writes the graph in the Graphviz DOT language. Nodes issuing system calls
+// directly are filled and list them, entry points are drawn with a double border.
+func writeDot(output io.Writer, graph *systract.Graph) {
+	printf(output, "digraph syscalls {\n")
+	printf(output, "\trankdir=LR;\n")
+	printf(output, "\tnode [shape=box, style=filled, fillcolor=\"%s\"];\n", indirectColour)
+
+	for _, n := range graph.Nodes {
+		attrs := []string{"label=\"" + dotLabel(n) + "\""}
+		if len(n.SystemCalls) > 0 {
+			attrs = append(attrs, "fillcolor=\""+directColour+"\"")
+		}
+		if n.EntryPoint {
+			attrs = append(attrs, "peripheries=2")
+		}
+		printf(output, "\t\"%s\" [%s];\n", dotEscaper.Replace(n.ID), strings.Join(attrs, ", "))
+	}
+
+	for _, e := range graph.Edges {
+		printf(output, "\t\"%s\" -> \"%s\";\n", dotEscaper.Replace(e.From), dotEscaper.Replace(e.To))
+	}
+
+	printf(output, "}\n")
+}
+
+// dotLabel returns the escaped id of the node, followed on a new line
+// by the system calls it issues directly.
+func dotLabel(n systract.GraphNode) string {
+	label := dotEscaper.Replace(n.ID)
+	if len(n.SystemCalls) > 0 {
+		label += `\n` + dotEscaper.Replace(syscallNames(n.SystemCalls))
+	}
+
+	return label
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// graphMLKeys are the attributes of nodes, the colour following whether they issue system calls directly.
+var graphMLKeys = []graphMLKey{
+	{ID: "package", For: "node", AttrName: "package", AttrType: "string"},
+	{ID: "syscalls", For: "node", AttrName: "syscalls", AttrType: "string"},
+	{ID: "direct", For: "node", AttrName: "direct", AttrType: "boolean"},
+	{ID: "entrypoint", For: "node", AttrName: "entrypoint", AttrType: "boolean"},
+	{ID: "color", For: "node", AttrName: "color", AttrType: "string"},
+}
+
+// writeGraphML writes the graph in the GraphML format.
+func writeGraphML(output io.Writer, graph *systract.Graph) error {
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys:  graphMLKeys,
+		Graph: graphMLGraph{ID: "syscalls", EdgeDefault: "directed"},
+	}
+
+	for _, n := range graph.Nodes {
+		colour := indirectColour
+		if len(n.SystemCalls) > 0 {
+			colour = directColour
+		}
+
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: n.ID, Data: []graphMLData{
+			{Key: "package", Value: n.Package},
+			{Key: "syscalls", Value: syscallNames(n.SystemCalls)},
+			{Key: "direct", Value: strconv.FormatBool(len(n.SystemCalls) > 0)},
+			{Key: "entrypoint", Value: strconv.FormatBool(n.EntryPoint)},
+			{Key: "color", Value: colour},
+		}})
+	}
+	for _, e := range graph.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: e.From, Target: e.To})
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	printf(output, "%s%s\n", xml.Header, data)
+	return nil
+}
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+var testGraph = &systract.Graph{
+	Arch: "amd64",
+	Nodes: []systract.GraphNode{
+		{ID: "main.main", Package: "main", EntryPoint: true},
+		{ID: `main.(*T).Write`, Package: "main", SystemCalls: []systract.SystemCall{{ID: 39, Name: "getpid"}, {ID: 1, Name: "write"}}},
+		{ID: `main."quoted"`, Package: "main"},
+	},
+	Edges: []systract.GraphEdge{{From: "main.main", To: `main.(*T).Write`}, {From: "main.main", To: `main."quoted"`}},
+}
+
+func TestWriteDot(t *testing.T) {
+	should := should.New(t)
+	var output bytes.Buffer
+
+	writeDot(&output, testGraph)
+
+	should.BeEqual("digraph syscalls {\n"+
+		"\trankdir=LR;\n"+
+		"\tnode [shape=box, style=filled, fillcolor=\"#ffffff\"];\n"+
+		"\t\"main.main\" [label=\"main.main\", peripheries=2];\n"+
+		"\t\"main.(*T).Write\" [label=\"main.(*T).Write\\ngetpid, write\", fillcolor=\"#f4a6a6\"];\n"+
+		"\t\"main.\\\"quoted\\\"\" [label=\"main.\\\"quoted\\\"\"];\n"+
+		"\t\"main.main\" -> \"main.(*T).Write\";\n"+
+		"\t\"main.main\" -> \"main.\\\"quoted\\\"\";\n"+
+		"}\n", output.String(), "should write nodes coloured by whether they issue syscalls directly")
+}
+
+func TestWriteGraphML(t *testing.T) {
+	should := should.New(t)
+	var output bytes.Buffer
+
+	err := writeGraphML(&output, &systract.Graph{
+		Nodes: testGraph.Nodes[:2],
+		Edges: testGraph.Edges[:1],
+	})
+
+	should.NotError(err, "should write graphml")
+	should.BeEqual(`<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="package" for="node" attr.name="package" attr.type="string"></key>
+  <key id="syscalls" for="node" attr.name="syscalls" attr.type="string"></key>
+  <key id="direct" for="node" attr.name="direct" attr.type="boolean"></key>
+  <key id="entrypoint" for="node" attr.name="entrypoint" attr.type="boolean"></key>
+  <key id="color" for="node" attr.name="color" attr.type="string"></key>
+  <graph id="syscalls" edgedefault="directed">
+    <node id="main.main">
+      <data key="package">main</data>
+      <data key="syscalls"></data>
+      <data key="direct">false</data>
+      <data key="entrypoint">true</data>
+      <data key="color">#ffffff</data>
+    </node>
+    <node id="main.(*T).Write">
+      <data key="package">main</data>
+      <data key="syscalls">getpid, write</data>
+      <data key="direct">true</data>
+      <data key="entrypoint">false</data>
+      <data key="color">#f4a6a6</data>
+    </node>
+    <edge source="main.main" target="main.(*T).Write"></edge>
+  </graph>
+</graphml>
+`, output.String(), "should write nodes coloured by whether they issue syscalls directly")
+}
+
+func TestRun_Graph(t *testing.T) {
+	graph := &systract.Graph{
+		Nodes: []systract.GraphNode{
+			{ID: "main.main", Package: "main", EntryPoint: true},
+			{ID: "syscall.Getpid", Package: "syscall", SystemCalls: []systract.SystemCall{{ID: 39, Name: "getpid"}}},
+		},
+		Edges: []systract.GraphEdge{{From: "main.main", To: "syscall.Getpid"}},
+	}
+
+	assertThat := func(assumption string, args []string, exportErr error, expectedOpts systract.GraphOptions,
+		expected string, expectedExitCode int) {
+
+		should := should.New(t)
+		var stdOut, stdErr bytes.Buffer
+		exitCode := 0
+
+		Run(&stdOut, &stdErr, args, func(source systract.SourceReader) (*systract.Result, error) {
+			t.Error("should not analyse sources exported as graphs")
+			return nil, nil
+		}, func(source systract.SourceReader, opts systract.GraphOptions) (*systract.Graph, error) {
+			should.BeEqual(expectedOpts, opts, assumption)
+			if exportErr != nil {
+				return nil, exportErr
+			}
+			return graph, nil
+		}, func(code int) {
+			exitCode = code
+		})
+
+		should.BeEqual(expectedExitCode, exitCode, assumption)
+		should.BeEqual(expected, stdOut.String(), assumption)
+	}
+
+	dot := "digraph syscalls {\n" +
+		"\trankdir=LR;\n" +
+		"\tnode [shape=box, style=filled, fillcolor=\"#ffffff\"];\n" +
+		"\t\"main.main\" [label=\"main.main\", peripheries=2];\n" +
+		"\t\"syscall.Getpid\" [label=\"syscall.Getpid\\ngetpid\", fillcolor=\"#f4a6a6\"];\n" +
+		"\t\"main.main\" -> \"syscall.Getpid\";\n" +
+		"}\n"
+
+	assertThat("should export the graph as dot",
+		[]string{"gosystract", "--output=dot", "--syscalls=getpid", "-d", "filename"}, nil,
+		systract.GraphOptions{Syscalls: []string{"getpid"}}, dot, 0)
+	assertThat("should pass graph options to the exporter",
+		[]string{"gosystract", "--output=dot", "--collapse-packages", "-d", "filename"}, nil,
+		systract.GraphOptions{CollapsePackages: true}, dot, 0)
+	assertThat("should exit with code 1 when the graph cannot be exported",
+		[]string{"gosystract", "--output=graphml", "--syscalls=not_a_syscall", "-d", "filename"},
+		errors.New("unknown syscall: not_a_syscall"), systract.GraphOptions{Syscalls: []string{"not_a_syscall"}}, "", 1)
+}
+
+func TestParseInputValues_Graph(t *testing.T) {
+	should := should.New(t)
+
+	opts, err := parseInputValues([]string{"gosystract", "--output=graphml", "--collapse-packages", "--syscalls=write,231", "filename"})
+
+	should.NotError(err, "should parse graph flags")
+	should.BeEqual("graphml", opts.output, "should parse graphml output")
+	should.BeEqual(systract.GraphOptions{CollapsePackages: true, Syscalls: []string{"write", "231"}}, opts.graph,
+		"should parse graph options")
+
+	_, err = parseInputValues([]string{"gosystract", "--collapse-packages", "filename"})
+	should.Error(err, "should reject --collapse-packages with non-graph outputs")
+
+	_, err = parseInputValues([]string{"gosystract", "--output=json", "--syscalls=write", "filename"})
+	should.Error(err, "should reject --syscalls with non-graph outputs")
+}
+
+// unexpectedExport returns an exporter failing the test, for sources not exported as graphs.
+func unexpectedExport(t *testing.T) func(systract.SourceReader, systract.GraphOptions) (*systract.Graph, error) {
+	return func(source systract.SourceReader, opts systract.GraphOptions) (*systract.Graph, error) {
+		t.Error("should not export sources as graphs")
+		return nil, nil
+	}
+}
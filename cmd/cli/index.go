@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+// graphFileExt is appended to the source file name when no output file is given.
+const graphFileExt string = ".sgx"
+
+/*
+RunIndex saves the symbol graph of the source, so that all other commands can
+analyse it instead of the source. The parameter args contains the executable name,
+the index command, the optional flags followed by the filepath. The symbol graph
+is written into the file given by -o, defaulting to the filepath with .sgx appended.
+
+Example:
+[]string{ "gosystract", "index", "filename", "-o", "app.sgx"}
+*/
+func RunIndex(stdOut io.Writer, stdErr io.Writer, args []string,
+	save func(source systract.SourceReader, filePath string) error, exit func(int)) {
+
+	args, graphFile, err := parseGraphFile(args)
+	if err == nil && len(args) < 3 {
+		err = errors.New(invalidSyntaxMessage)
+	}
+	if err != nil {
+		showUsage(stdErr, err, exit)
+		return
+	}
+
+	opts, err := parseInputValues(append([]string{args[0]}, args[2:]...))
+	if err != nil {
+		showUsage(stdErr, err, exit)
+		return
+	}
+	if graphFile == "" {
+		graphFile = opts.fileName + graphFileExt
+	}
+
+	if err := save(getSourceReader(opts), graphFile); err != nil {
+		printf(stdErr, "\nerror: %s\n", err)
+		exit(1)
+		return
+	}
+
+	printf(stdOut, "symbol graph of %s saved into %s\n", opts.fileName, graphFile)
+}
+
+// parseGraphFile removes the output file flag from args, returning its value.
+func parseGraphFile(args []string) ([]string, string, error) {
+	remaining := make([]string, 0, len(args))
+	graphFile := ""
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-o":
+			if i+1 >= len(args) {
+				return nil, "", errors.New("missing output file")
+			}
+			i++
+			graphFile = args[i]
+		case strings.HasPrefix(args[i], "-o="):
+			graphFile = strings.TrimPrefix(args[i], "-o=")
+		default:
+			remaining = append(remaining, args[i])
+		}
+	}
+
+	return remaining, graphFile, nil
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+func TestRunIndex(t *testing.T) {
+	assertThat := func(assumption string, args []string, saveErr error,
+		expectedSource interface{}, expectedFile, expected string, expectedExitCode int) {
+
+		should := should.New(t)
+		var stdOut, stdErr bytes.Buffer
+		exitCode := 0
+		actualFile := ""
+
+		RunIndex(&stdOut, &stdErr, args, func(source systract.SourceReader, filePath string) error {
+			should.HaveSameType(expectedSource, source, assumption)
+			actualFile = filePath
+			return saveErr
+		}, func(code int) {
+			exitCode = code
+		})
+
+		should.BeEqual(expectedExitCode, exitCode, assumption)
+		should.BeEqual(expectedFile, actualFile, assumption)
+		should.BeEqual(expected, stdOut.String(), assumption)
+	}
+
+	assertThat("should save symbol graph into output file",
+		[]string{"gosystract", "index", "app", "-o", "app.sgx"}, nil,
+		&systract.ELFReader{}, "app.sgx", "symbol graph of app saved into app.sgx\n", 0)
+	assertThat("should accept output file before the source",
+		[]string{"gosystract", "index", "-o=out.sgx", "--dumpfile", "app.dump"}, nil,
+		&systract.DumpReader{}, "out.sgx", "symbol graph of app.dump saved into out.sgx\n", 0)
+	assertThat("should default output file to the source with .sgx appended",
+		[]string{"gosystract", "index", "--bounded-memory", "app"}, nil,
+		&systract.BoundedSource{}, "app.sgx", "symbol graph of app saved into app.sgx\n", 0)
+	assertThat("should exit with code 1 when the graph cannot be saved",
+		[]string{"gosystract", "index", "app"}, errors.New("file does not exist or permission denied"),
+		&systract.ELFReader{}, "app.sgx", "", 1)
+	assertThat("should show usage without source",
+		[]string{"gosystract", "index", "-o", "app.sgx"}, nil, nil, "", "", 1)
+	assertThat("should show usage without output file",
+		[]string{"gosystract", "index", "app", "-o"}, nil, nil, "", "", 1)
+}
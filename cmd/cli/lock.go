@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"errors"
+	"io"
+
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+const defaultLockFile string = "syscalls.lock"
+
+/*
+RunCheck verifies that all syscalls found in the source are in the lockfile.
+The parameter args contains the executable name, the check command, the optional flags
+followed by the filepath.
+
+Example:
+[]string{ "gosystract", "check", "--lock", "syscalls.lock", "filename"}
+*/
+func RunCheck(stdOut io.Writer, stdErr io.Writer, args []string,
+	analyse func(source systract.SourceReader) (*systract.Result, error), exit func(int)) {
+
+	opts, result, ok := analyseCommand(stdErr, args, analyse, exit)
+	if !ok {
+		return
+	}
+
+	lock, err := systract.LoadLock(opts.lockFile)
+	if err != nil {
+		printf(stdErr, "\nerror: %s\n", err)
+		exit(1)
+		return
+	}
+
+	violations, err := lock.Check(result)
+	if err != nil {
+		printf(stdErr, "\nerror: %s\n", err)
+		exit(1)
+		return
+	}
+
+	writeViolations(stdOut, opts.lockFile, result, violations)
+	if len(violations) > 0 {
+		exit(lockViolationExitCode)
+		return
+	}
+
+	if opts.strict {
+		failOnUnresolved(stdErr, result, exit)
+	}
+}
+
+/*
+RunUpdate writes all syscalls found in the source into the lockfile.
+The parameter args contains the executable name, the update command, the optional flags
+followed by the filepath.
+
+Example:
+[]string{ "gosystract", "update", "--lock", "syscalls.lock", "filename"}
+*/
+func RunUpdate(stdOut io.Writer, stdErr io.Writer, args []string,
+	analyse func(source systract.SourceReader) (*systract.Result, error), exit func(int)) {
+
+	opts, result, ok := analyseCommand(stdErr, args, analyse, exit)
+	if !ok {
+		return
+	}
+
+	err := systract.SaveLock(opts.lockFile, systract.NewLock(result, opts.fileName))
+	if err != nil {
+		printf(stdErr, "\nerror: %s\n", err)
+		exit(1)
+		return
+	}
+
+	printf(stdOut, "%s updated with %d system calls\n", opts.lockFile, len(result.SystemCalls))
+}
+
+// analyseCommand parses the args of a command and analyses its source.
+func analyseCommand(stdErr io.Writer, args []string,
+	analyse func(source systract.SourceReader) (*systract.Result, error), exit func(int)) (options, *systract.Result, bool) {
+
+	if len(args) < 3 {
+		showUsage(stdErr, errors.New(invalidSyntaxMessage), exit)
+		return options{}, nil, false
+	}
+
+	opts, err := parseInputValues(append([]string{args[0]}, args[2:]...))
+	if err != nil {
+		showUsage(stdErr, err, exit)
+		return opts, nil, false
+	}
+
+	result, err := analyse(getSourceReader(opts))
+	if err != nil {
+		printf(stdErr, "\nerror: %s\n", err)
+		exit(1)
+		return opts, nil, false
+	}
+
+	return opts, result, true
+}
+
+func writeViolations(output io.Writer, lockFile string, result *systract.Result, violations []systract.SystemCall) {
+	if len(violations) == 0 {
+		printf(output, "all %d system calls found are in %s\n", len(result.SystemCalls), lockFile)
+		return
+	}
+
+	printf(output, "%d system calls found are not in %s:\n", len(violations), lockFile)
+	for _, s := range violations {
+		printf(output, "    %s (%d)\n", s.Name, s.ID)
+	}
+}
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+func TestRunCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	lockFile := filepath.Join(dir, "syscalls.lock")
+	systract.SaveLock(lockFile, &systract.Lock{Arch: "amd64", Binary: "app",
+		SystemCalls: []systract.SystemCall{{ID: 0, Name: "read"}, {ID: 1, Name: "write"}}})
+
+	assertThat := func(assumption string, args []string, result *systract.Result, analyseErr error,
+		expected string, expectedExitCode int, expectedErr string) {
+
+		should := should.New(t)
+		var stdOut, stdErr bytes.Buffer
+		exitCode := 0
+
+		RunCheck(&stdOut, &stdErr, args, func(source systract.SourceReader) (*systract.Result, error) {
+			return result, analyseErr
+		}, func(code int) {
+			exitCode = code
+		})
+
+		should.BeEqual(expectedExitCode, exitCode, assumption)
+		should.BeEqual(expected, stdOut.String(), assumption)
+		if expectedErr != "" {
+			should.BeEqual(expectedErr, stdErr.String(), assumption)
+		}
+	}
+
+	assertThat("should pass when all syscalls are in the lockfile",
+		[]string{"gosystract", "check", "--lock", lockFile, "app"},
+		&systract.Result{Arch: "amd64", SystemCalls: []systract.SystemCall{{ID: 1, Name: "write"}}}, nil,
+		"all 1 system calls found are in "+lockFile+"\n", 0, "")
+
+	assertThat("should exit with code 3 in strict mode when syscall sites were not resolved",
+		[]string{"gosystract", "check", "--strict", "--lock", lockFile, "app"},
+		&systract.Result{Arch: "amd64", SystemCalls: []systract.SystemCall{{ID: 1, Name: "write"}},
+			Unresolved: []systract.Unresolved{{Reason: systract.UnresolvedUnknownID, ID: 500,
+				Location: systract.Location{Symbol: "main.main", File: "main.go", Line: 10}}}}, nil,
+		"all 1 system calls found are in "+lockFile+"\n", 3,
+		"\nerror: 1 syscall sites or calls could not be resolved:\n    main.go:10 (main.main): unknown syscall number 500\n")
+
+	assertThat("should list violations and exit with code 2",
+		[]string{"gosystract", "check", "--lock=" + lockFile, "app"},
+		&systract.Result{Arch: "amd64", SystemCalls: []systract.SystemCall{{ID: 1, Name: "write"}, {ID: 101, Name: "ptrace"}, {ID: 165, Name: "mount"}}}, nil,
+		"2 system calls found are not in "+lockFile+":\n    mount (165)\n    ptrace (101)\n", 2, "")
+
+	assertThat("should error for different architectures",
+		[]string{"gosystract", "check", "--lock", lockFile, "app"},
+		&systract.Result{Arch: "arm64", SystemCalls: []systract.SystemCall{}}, nil,
+		"", 1, "\nerror: architecture mismatch: lockfile is amd64 but binary is arm64\n")
+
+	assertThat("should error when lockfile does not exist",
+		[]string{"gosystract", "check", "--lock", filepath.Join(dir, "missing.lock"), "app"},
+		&systract.Result{Arch: "amd64"}, nil,
+		"", 1, "\nerror: lockfile does not exist or permission denied\n")
+
+	assertThat("should error when source cannot be analysed",
+		[]string{"gosystract", "check", "--lock", lockFile, "app"},
+		nil, errors.New("could not extract syscalls"),
+		"", 1, "\nerror: could not extract syscalls\n")
+
+	assertThat("should show usage when file is missing",
+		[]string{"gosystract", "check"}, nil, nil, "", 1, "")
+}
+
+func TestRunUpdate(t *testing.T) {
+	should := should.New(t)
+	dir, err := ioutil.TempDir("", "lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	lockFile := filepath.Join(dir, "syscalls.lock")
+	var stdOut, stdErr bytes.Buffer
+	exitCode := 0
+
+	RunUpdate(&stdOut, &stdErr, []string{"gosystract", "update", "--lock", lockFile, "bin/app"},
+		func(source systract.SourceReader) (*systract.Result, error) {
+			return &systract.Result{Arch: "amd64", GoVersion: "go1.13.4",
+				SystemCalls: []systract.SystemCall{{ID: 1, Name: "write"}, {ID: 0, Name: "read"}}}, nil
+		}, func(code int) {
+			exitCode = code
+		})
+
+	should.BeEqual(0, exitCode, "should not error")
+	should.BeEqual(lockFile+" updated with 2 system calls\n", stdOut.String(), "should report lockfile update")
+
+	lock, err := systract.LoadLock(lockFile)
+	should.NotError(err, "should write lockfile")
+	should.BeEqual(&systract.Lock{Arch: "amd64", GoVersion: "go1.13.4", Binary: "bin/app",
+		SystemCalls: []systract.SystemCall{{ID: 0, Name: "read"}, {ID: 1, Name: "write"}}},
+		lock, "should record syscalls and traceability information")
+}
+
+func TestParseInputValues_Lock(t *testing.T) {
+	assertThat := func(assumption string, args []string, expectedLock, expectedFile string) {
+		should := should.New(t)
+
+		opts, err := parseInputValues(args)
+
+		should.NotError(err, assumption)
+		should.BeEqual(expectedLock, opts.lockFile, assumption)
+		should.BeEqual(expectedFile, opts.fileName, assumption)
+	}
+
+	assertThat("should default to syscalls.lock", []string{"gosystract", "app"}, "syscalls.lock", "app")
+	assertThat("should support lock flag with value", []string{"gosystract", "--lock=a.lock", "app"}, "a.lock", "app")
+	assertThat("should support lock flag followed by value", []string{"gosystract", "--lock", "a.lock", "app"}, "a.lock", "app")
+
+	_, err := parseInputValues([]string{"gosystract", "--lock", "app"})
+	should.New(t).Error(err, "should error when lock flag is not followed by a lockfile and the file path")
+}
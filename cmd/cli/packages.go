@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"io"
+	"strings"
+
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+// writePackages writes the syscalls grouped by module and then by the package issuing them.
+func writePackages(output io.Writer, packages []systract.PackageSyscalls) {
+	if len(packages) == 0 {
+		printf(output, "no systems calls were found\n")
+		return
+	}
+
+	for _, m := range systract.GroupByModule(packages) {
+		printf(output, "%s (%d system calls)\n", m.Module, len(m.SystemCalls))
+		for _, p := range m.Packages {
+			printf(output, "    %s -> %s\n", p.Package, syscallNames(p.SystemCalls))
+		}
+	}
+}
+
+func syscallNames(syscalls []systract.SystemCall) string {
+	names := make([]string, 0, len(syscalls))
+	for _, s := range syscalls {
+		names = append(names, s.Name)
+	}
+
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+func TestWritePackages(t *testing.T) {
+	assertThat := func(assumption string, packages []systract.PackageSyscalls, expected string) {
+		should := should.New(t)
+		var output bytes.Buffer
+
+		writePackages(&output, packages)
+
+		should.BeEqual(expected, output.String(), assumption)
+	}
+
+	assertThat("should show message when no syscalls are found", []systract.PackageSyscalls{},
+		"no systems calls were found\n")
+	assertThat("should group packages by module",
+		[]systract.PackageSyscalls{
+			{Package: "github.com/jsipprell/keyctl", Module: "github.com/jsipprell/keyctl",
+				SystemCalls: []systract.SystemCall{{ID: 248, Name: "add_key"}, {ID: 250, Name: "keyctl"}}},
+			{Package: "runtime", Module: "std", SystemCalls: []systract.SystemCall{{ID: 231, Name: "exit_group"}}},
+			{Package: "syscall", Module: "std", SystemCalls: []systract.SystemCall{{ID: 72, Name: "fcntl"}}},
+		},
+		`github.com/jsipprell/keyctl (2 system calls)
+    github.com/jsipprell/keyctl -> add_key, keyctl
+std (2 system calls)
+    runtime -> exit_group
+    syscall -> fcntl
+`)
+}
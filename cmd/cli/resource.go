@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+const (
+	// maxResourceNameLength is the maximum length of a DNS subdomain name.
+	maxResourceNameLength int    = 253
+	defaultResourceName   string = "gosystract"
+
+	seccompProfileTemplate string = `apiVersion: security-profiles-operator.x-k8s.io/v1beta1
+kind: SeccompProfile
+metadata:
+  name: {{ quote .Name }}
+{{- if .Namespace }}
+  namespace: {{ quote .Namespace }}
+{{- end }}
+{{- if .Labels }}
+  labels:
+{{- range .Labels }}
+    {{ quote .Key }}: {{ quote .Value }}
+{{- end }}
+{{- end }}
+spec:
+  defaultAction: {{ quote .Profile.DefaultAction }}
+{{- if .Profile.Architectures }}
+  architectures:
+{{- range .Profile.Architectures }}
+  - {{ quote . }}
+{{- end }}
+{{- end }}
+{{- if .Profile.Syscalls }}
+  syscalls:
+{{- range .Profile.Syscalls }}
+  - action: {{ quote .Action }}
+    names:
+{{- range .Names }}
+    - {{ quote . }}
+{{- end }}
+{{- end }}
+{{- else }}
+  syscalls: []
+{{- end }}
+`
+)
+
+var invalidResourceNameChars = regexp.MustCompile("[^a-z0-9.-]+")
+
+type label struct {
+	Key   string
+	Value string
+}
+
+// resourceOptions defines the metadata of generated kubernetes resources.
+type resourceOptions struct {
+	name      string
+	namespace string
+	labels    []label
+}
+
+// parseLabels parses comma-separated key=value pairs, sorted by key.
+func parseLabels(value string) ([]label, error) {
+	var labels []label
+	for _, pair := range strings.Split(value, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid label: %s", pair)
+		}
+		labels = append(labels, label{Key: kv[0], Value: kv[1]})
+	}
+
+	sort.SliceStable(labels, func(i, j int) bool {
+		return labels[i].Key < labels[j].Key
+	})
+
+	return labels, nil
+}
+
+// resourceName converts the file name into a valid kubernetes resource name.
+func resourceName(fileName string) string {
+	name := strings.ToLower(filepath.Base(fileName))
+	name = invalidResourceNameChars.ReplaceAllString(name, "-")
+	if len(name) > maxResourceNameLength {
+		name = name[:maxResourceNameLength]
+	}
+	name = strings.Trim(name, ".-")
+
+	if name == "" {
+		return defaultResourceName
+	}
+
+	return name
+}
+
+// writeSeccompProfileResource writes the results as a Security Profiles Operator
+// SeccompProfile manifest.
+func writeSeccompProfileResource(output io.Writer, result *systract.Result,
+	opts systract.SeccompOptions, resource resourceOptions) error {
+
+	profile, err := newSeccompProfile(result, opts)
+	if err != nil {
+		return err
+	}
+
+	t := template.Must(template.New("seccompprofile").Funcs(template.FuncMap{
+		"quote": quote,
+	}).Parse(seccompProfileTemplate))
+
+	return t.Execute(output, struct {
+		Name      string
+		Namespace string
+		Labels    []label
+		Profile   *systract.SeccompProfile
+	}{resource.name, resource.namespace, resource.labels, profile})
+}
+
+// quote returns value as a double-quoted yaml string.
+func quote(value string) string {
+	b, _ := json.Marshal(value)
+	return string(b)
+}
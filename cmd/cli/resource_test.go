@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+func TestParseLabels(t *testing.T) {
+	assertThat := func(assumption, value string, expected []label, expectedErr bool) {
+		should := should.New(t)
+
+		actual, err := parseLabels(value)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should parse single label", "app=web", []label{{"app", "web"}}, false)
+	assertThat("should sort labels by key", "team=a,app=web", []label{{"app", "web"}, {"team", "a"}}, false)
+	assertThat("should support empty values and equal signs", "a=,b=c=d", []label{{"a", ""}, {"b", "c=d"}}, false)
+	assertThat("should error for labels without values", "app", nil, true)
+	assertThat("should error for labels without keys", "=web", nil, true)
+}
+
+func TestResourceName(t *testing.T) {
+	assertThat := func(assumption, fileName, expected string) {
+		should := should.New(t)
+
+		actual := resourceName(fileName)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should use file name", "/usr/bin/app", "app")
+	assertThat("should lower case and replace invalid characters", "./My_App v2.dump", "my-app-v2.dump")
+	assertThat("should trim invalid leading characters", ".hidden", "hidden")
+	assertThat("should default when no valid characters are left", "___", "gosystract")
+}
+
+func TestWriteSeccompProfileResource(t *testing.T) {
+	assertThat := func(assumption string, syscalls []systract.SystemCall, resource resourceOptions, expected string) {
+		should := should.New(t)
+		var output bytes.Buffer
+
+		err := writeSeccompProfileResource(&output, &systract.Result{Arch: "arm64", SystemCalls: syscalls},
+			systract.SeccompOptions{DefaultAction: systract.SeccompActLog}, resource)
+
+		should.NotError(err, assumption)
+		should.BeEqual(expected, output.String(), assumption)
+	}
+
+	assertThat("should write minimal manifest", []systract.SystemCall{},
+		resourceOptions{name: "app"},
+		`apiVersion: security-profiles-operator.x-k8s.io/v1beta1
+kind: SeccompProfile
+metadata:
+  name: "app"
+spec:
+  defaultAction: "SCMP_ACT_LOG"
+  architectures:
+  - "SCMP_ARCH_AARCH64"
+  syscalls: []
+`)
+
+	assertThat("should write namespace, labels and syscalls",
+		[]systract.SystemCall{{ID: 64, Name: "write"}, {ID: 94, Name: "exit_group"}},
+		resourceOptions{name: "app", namespace: "prod", labels: []label{{"app.kubernetes.io/name", "web"}}},
+		`apiVersion: security-profiles-operator.x-k8s.io/v1beta1
+kind: SeccompProfile
+metadata:
+  name: "app"
+  namespace: "prod"
+  labels:
+    "app.kubernetes.io/name": "web"
+spec:
+  defaultAction: "SCMP_ACT_LOG"
+  architectures:
+  - "SCMP_ARCH_AARCH64"
+  syscalls:
+  - action: "SCMP_ACT_ALLOW"
+    names:
+    - "exit_group"
+    - "write"
+`)
+}
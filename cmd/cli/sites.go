@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"io"
+	"sort"
+
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+type syscallSite struct {
+	systract.Location
+	syscall systract.SystemCall
+}
+
+// writeSites writes the locations issuing each syscall, in the format of compiler diagnostics,
+// e.g. /app/main.go:10: write (1) in main.main.
+func writeSites(output io.Writer, result *systract.Result) {
+	sites := make([]syscallSite, 0)
+	for _, s := range result.SystemCalls {
+		for _, location := range s.Sites {
+			sites = append(sites, syscallSite{Location: location, syscall: systract.SystemCall{ID: s.ID, Name: s.Name}})
+		}
+	}
+
+	if len(sites) == 0 {
+		printf(output, "no systems calls were found\n")
+		return
+	}
+
+	sort.SliceStable(sites, func(i, j int) bool {
+		a, b := sites[i], sites[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.syscall.Name < b.syscall.Name
+	})
+
+	for _, s := range sites {
+		printf(output, "%s:%d: %s (%d) in %s\n", s.File, s.Line, s.syscall.Name, s.syscall.ID, s.Symbol)
+	}
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+func TestWriteSites(t *testing.T) {
+	assertThat := func(assumption string, result *systract.Result, expected string) {
+		should := should.New(t)
+		var output bytes.Buffer
+
+		writeSites(&output, result)
+
+		should.BeEqual(expected, output.String(), assumption)
+	}
+
+	assertThat("should show message when no syscalls are found", &systract.Result{}, "no systems calls were found\n")
+	assertThat("should write sites sorted by file and line", &systract.Result{
+		SystemCalls: []systract.SystemCall{
+			{ID: 1, Name: "write", Sites: []systract.Location{
+				{Symbol: "runtime.write1", File: "/go/src/runtime/sys_linux_amd64.s", Line: 97},
+				{Symbol: "main.main", File: "/app/main.go", Line: 12},
+			}},
+			{ID: 39, Name: "getpid", Sites: []systract.Location{
+				{Symbol: "main.main", File: "/app/main.go", Line: 10},
+			}},
+		},
+	}, "/app/main.go:10: getpid (39) in main.main\n"+
+		"/app/main.go:12: write (1) in main.main\n"+
+		"/go/src/runtime/sys_linux_amd64.s:97: write (1) in runtime.write1\n")
+}
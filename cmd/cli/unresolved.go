@@ -0,0 +1,21 @@
+package cli
+
+import (
+	"io"
+
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+// failOnUnresolved lists the syscall sites and calls which could not be resolved, if any,
+// and exits with unresolvedExitCode.
+func failOnUnresolved(stdErr io.Writer, result *systract.Result, exit func(int)) {
+	if len(result.Unresolved) == 0 {
+		return
+	}
+
+	printf(stdErr, "\nerror: %d syscall sites or calls could not be resolved:\n", len(result.Unresolved))
+	for _, u := range result.Unresolved {
+		printf(stdErr, "    %s\n", u)
+	}
+	exit(unresolvedExitCode)
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+func TestRun_Strict(t *testing.T) {
+	unresolved := []systract.Unresolved{{
+		Reason:   systract.UnresolvedDynamic,
+		Location: systract.Location{Symbol: "main.main", File: "main.go", Line: 10},
+	}}
+
+	assertThat := func(assumption string, args []string, unresolved []systract.Unresolved,
+		expected string, expectedExitCode int, expectedErr string) {
+
+		should := should.New(t)
+		var stdOut, stdErr bytes.Buffer
+		exitCode := 0
+
+		Run(&stdOut, &stdErr, args, func(source systract.SourceReader) (*systract.Result, error) {
+			return &systract.Result{Arch: "amd64", SystemCalls: []systract.SystemCall{{ID: 1, Name: "write"}},
+				Unresolved: unresolved}, nil
+		}, unexpectedExport(t), func(code int) {
+			exitCode = code
+		})
+
+		should.BeEqual(expectedExitCode, exitCode, assumption)
+		should.BeEqual(expected, stdOut.String(), assumption)
+		should.BeEqual(expectedErr, stdErr.String(), assumption)
+	}
+
+	assertThat("should pass when syscall sites were not resolved",
+		[]string{"gosystract", "filename"}, unresolved,
+		"1 system calls found:\n    write (1)\n", 0, "")
+	assertThat("should exit with code 3 in strict mode when syscall sites were not resolved",
+		[]string{"gosystract", "--strict", "filename"}, unresolved,
+		"1 system calls found:\n    write (1)\n", 3,
+		"\nerror: 1 syscall sites or calls could not be resolved:\n    main.go:10 (main.main): dynamic syscall number\n")
+	assertThat("should pass in strict mode when all syscall sites were resolved",
+		[]string{"gosystract", "--strict", "filename"}, nil,
+		"1 system calls found:\n    write (1)\n", 0, "")
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"errors"
+	"io"
+
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+/*
+RunWhy writes the call chains from the entry points of the source to the given syscall.
+The parameter args contains the executable name, the why command, the optional flags
+followed by the syscall name or id and the filepath.
+
+Example:
+[]string{ "gosystract", "why", "--dumpfile", "write", "filename"}
+*/
+func RunWhy(stdOut io.Writer, stdErr io.Writer, args []string,
+	why func(source systract.SourceReader, syscall string) ([]systract.CallChain, error), exit func(int)) {
+
+	if len(args) < 4 {
+		showUsage(stdErr, errors.New(invalidSyntaxMessage), exit)
+		return
+	}
+
+	syscall := args[len(args)-2]
+	opts, err := parseInputValues(append([]string{args[0]}, args[2:]...))
+	if err != nil {
+		showUsage(stdErr, err, exit)
+		return
+	}
+
+	chains, err := why(getSourceReader(opts), syscall)
+	if err != nil {
+		printf(stdErr, "\nerror: %s\n", err)
+		exit(1)
+		return
+	}
+
+	writeCallChains(stdOut, syscall, chains)
+}
+
+func writeCallChains(output io.Writer, syscall string, chains []systract.CallChain) {
+	if len(chains) == 0 {
+		printf(output, "%s is not reachable from any entry point\n", syscall)
+		return
+	}
+
+	printf(output, "%s is reachable through %d call chains:\n", syscall, len(chains))
+	for _, chain := range chains {
+		printf(output, "    %s\n", chain)
+	}
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+func TestRunWhy(t *testing.T) {
+	assertThat := func(assumption string, args []string,
+		stub func() ([]systract.CallChain, error), expected string,
+		expectedToErr bool, expectedErr string) {
+
+		should := should.New(t)
+		var stdOut, stdErr bytes.Buffer
+		var hasErrored bool
+
+		RunWhy(&stdOut, &stdErr, args, func(source systract.SourceReader, syscall string) ([]systract.CallChain, error) {
+			should.BeEqual("write", syscall, assumption)
+			should.HaveSameType(&systract.DumpReader{}, source, assumption)
+			return stub()
+		}, func(code int) {
+			hasErrored = true
+		})
+
+		should.BeEqual(expectedToErr, hasErrored, assumption)
+		should.BeEqual(expected, stdOut.String(), assumption)
+		if expectedErr != "" {
+			should.BeEqual(expectedErr, stdErr.String(), assumption)
+		}
+	}
+
+	assertThat("should show call chains found",
+		[]string{"gosystract", "why", "-d", "write", "filename"},
+		func() ([]systract.CallChain, error) {
+			return []systract.CallChain{{"main.main", "os.Exit"}, {"main.main", "fmt.Println", "os.(*File).Write"}}, nil
+		},
+		"write is reachable through 2 call chains:\n    main.main -> os.Exit\n    main.main -> fmt.Println -> os.(*File).Write\n",
+		false, "")
+
+	assertThat("should show message when syscall is not reachable",
+		[]string{"gosystract", "why", "-d", "write", "filename"},
+		func() ([]systract.CallChain, error) { return []systract.CallChain{}, nil },
+		"write is not reachable from any entry point\n", false, "")
+
+	assertThat("should show error when why failed",
+		[]string{"gosystract", "why", "-d", "write", "filename"},
+		func() ([]systract.CallChain, error) { return nil, errors.New("unknown syscall for amd64: write") },
+		"", true, "\nerror: unknown syscall for amd64: write\n")
+
+	assertThat("should show usage when syscall is missing",
+		[]string{"gosystract", "why", "filename"},
+		func() ([]systract.CallChain, error) { return nil, nil },
+		"", true, "")
+}
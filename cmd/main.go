@@ -8,5 +8,25 @@ import (
 )
 
 func main() {
-	cli.Run(os.Stdout, os.Stderr, os.Args, systract.Extract, os.Exit)
+	command := ""
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
+	switch command {
+	case "why":
+		cli.RunWhy(os.Stdout, os.Stderr, os.Args, systract.Why, os.Exit)
+	case "diff":
+		cli.RunDiff(os.Stdout, os.Stderr, os.Args, systract.Analyse, os.Exit)
+	case "check":
+		cli.RunCheck(os.Stdout, os.Stderr, os.Args, systract.Analyse, os.Exit)
+	case "update":
+		cli.RunUpdate(os.Stdout, os.Stderr, os.Args, systract.Analyse, os.Exit)
+	case "index":
+		cli.RunIndex(os.Stdout, os.Stderr, os.Args, systract.SaveGraph, os.Exit)
+	case "cache":
+		cli.RunCache(os.Stdout, os.Stderr, os.Args, os.Exit)
+	default:
+		cli.Run(os.Stdout, os.Stderr, os.Args, systract.Analyse, systract.ExportGraph, os.Exit)
+	}
 }
@@ -44,6 +44,19 @@ func TestMain(t *testing.T) {
 	assertThat("should return exit_group call for single-syscall.dump",
 		strings.Split("gosystract --dumpfile ../test/single-syscall.dump", " "),
 		"1 system calls found:\n    exit_group (231)\n")
+	assertThat("should explain why exit_group is called in single-syscall.dump",
+		strings.Split("gosystract why --dumpfile exit_group ../test/single-syscall.dump", " "),
+		"exit_group is reachable through 1 call chains:\n    main.main\n")
+	assertThat("should return exit_group call for the symbol graph of single-syscall.dump",
+		strings.Split("gosystract ../test/single-syscall.sgx", " "),
+		"1 system calls found:\n    exit_group (231)\n")
+	assertThat("should not exit in strict mode as all calls of simple-app are resolved",
+		strings.Split("gosystract --strict --template=resolved ../test/simple-app", " "),
+		"resolved")
+	assertThat("should generate seccomp profile for arm64-single-syscall.dump",
+		strings.Split("gosystract --output=seccomp --default-action=SCMP_ACT_KILL_PROCESS --dumpfile ../test/arm64-single-syscall.dump", " "),
+		"{\n  \"defaultAction\": \"SCMP_ACT_KILL_PROCESS\",\n  \"architectures\": [\n    \"SCMP_ARCH_AARCH64\"\n  ],\n"+
+			"  \"syscalls\": [\n    {\n      \"names\": [\n        \"exit_group\"\n      ],\n      \"action\": \"SCMP_ACT_ALLOW\"\n    }\n  ]\n}\n")
 }
 
 func TestMain_ErrorCodes(t *testing.T) {
@@ -73,10 +86,43 @@ func TestMain_ErrorCodes(t *testing.T) {
 		`gosystract version [ not set ]
 Usage:
 gosystrac [flags] filePath
+gosystrac why [flags] syscall filePath
+gosystrac diff [flags] filePath filePath
+gosystrac check [flags] filePath
+gosystrac update [flags] filePath
+gosystrac index [flags] filePath [-o graphFile]
+gosystrac cache prune [--cache-dir=dir] [--max-age=duration] [--max-size=bytes]
+
+Commands:
+	why               Shows the call chains from the entry points to the syscall name or id.
+	diff              Shows syscalls added and removed between two executables, dumps or json results.
+	                  Exits with code 2 when syscalls were added.
+	check             Checks the syscalls found against the lockfile.
+	                  Exits with code 2 when syscalls not in the lockfile were found.
+	update            Writes the syscalls found into the lockfile.
+	index             Saves the symbol graph parsed, which all commands accept instead of the file.
+	cache prune       Removes cached results of other versions, older than --max-age or beyond --max-size.
 
 Flags:
 	--dumpfile, -d    Handles a dump file instead of a go executable.
+	--objdump         Disassembles the go executable using go tool objdump.
 	--template	  Defines a go template for the results.
+	--output          Defines the output format: text (default), json, packages, sites, seccomp, seccompprofile, dot or graphml.
+	--default-action  Seccomp default action: SCMP_ACT_ERRNO (default), SCMP_ACT_KILL_PROCESS or SCMP_ACT_LOG.
+	--errno           Seccomp errno returned by SCMP_ACT_ERRNO, defaults to 1 (EPERM).
+	--arch            Comma-separated seccomp architectures, defaults to the one detected.
+	--name            SeccompProfile name, defaults to the file name.
+	--namespace       SeccompProfile namespace.
+	--labels          Comma-separated SeccompProfile labels, e.g. app=web,team=a.
+	--lock            Lockfile used by check and update, defaults to syscalls.lock.
+	--syscall-wrapper Additional syscall wrapper, as name[:trap argument position], e.g. pkg.rawSyscall:1.
+	--strict          Exits with code 3 when syscall sites or calls could not be resolved.
+	--bounded-memory  Keeps parsed symbols in a temporary on-disk index, loading each only while walked.
+	--cache           Caches results by go build id, or file checksum, in the user cache directory.
+	--cache-dir       Caches results in the directory provided instead.
+	--no-cache        Disables the cache, overriding --cache and --cache-dir.
+	--collapse-packages Merges the symbols of each package into a single node of dot and graphml outputs.
+	--syscalls        Comma-separated syscall names or ids the dot and graphml outputs are restricted to.
 
 error: invalid syntax
 `)
@@ -0,0 +1,53 @@
+package systract
+
+// callingConvention defines how go functions receive their arguments.
+type callingConvention int
+
+const (
+	// unknownConvention is used when the go version of the source is not known,
+	// accepting arguments passed in both ways.
+	unknownConvention callingConvention = iota
+	// stackConvention passes arguments on the stack, as done by ABI0.
+	stackConvention
+	// registerConvention passes arguments in registers, as done by ABIInternal
+	// since go 1.17 on amd64 and later releases on other architectures.
+	registerConvention
+)
+
+// getCallingConvention returns the calling convention used by go code built
+// for the architecture with the given go version.
+func getCallingConvention(arch *archSpec, goVersion string) callingConvention {
+	minor, ok := goMinorVersion(goVersion)
+	if !ok {
+		return unknownConvention
+	}
+
+	if arch.registerABISince > 0 && minor >= arch.registerABISince {
+		return registerConvention
+	}
+
+	return stackConvention
+}
+
+// argLocations returns where the caller stores the argument at the zero-based position pos.
+// The slice returned may be shared with other calls and must not be modified.
+func (c callingConvention) argLocations(arch *archSpec, pos int) []string {
+	if pos < len(arch.argLocations[c]) {
+		return arch.argLocations[c][pos]
+	}
+
+	return c.locations(arch, pos)
+}
+
+// locations builds where the caller stores the argument at the zero-based position pos.
+func (c callingConvention) locations(arch *archSpec, pos int) []string {
+	locations := make([]string, 0, 2)
+	if c != stackConvention && pos < len(arch.registerArgs) {
+		locations = append(locations, arch.registerArgs[pos])
+	}
+	if c != registerConvention {
+		locations = append(locations, arch.stackArgs.slot(pos))
+	}
+
+	return locations
+}
@@ -0,0 +1,77 @@
+package systract
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestExtract_E2E_Toolchains(t *testing.T) {
+	assertThat := func(assumption, fileName string, expected []SystemCall) {
+		should := should.New(t)
+		filePath, _ := filepath.Abs(fileName)
+
+		actual, err := Extract(NewDumpReader(filePath))
+
+		should.NotError(err, assumption)
+		should.HaveSameItems(keys(expected), keys(actual), assumption)
+	}
+
+	assertThat("should find syscalls using the stack-based calling convention", "../../test/go1.16-syscalls.dump",
+		[]SystemCall{{ID: 39, Name: "getpid"}, {ID: 231, Name: "exit_group"}})
+	assertThat("should find syscalls using the register-based calling convention", "../../test/go1.21-syscalls.dump",
+		[]SystemCall{{ID: 39, Name: "getpid"}, {ID: 58, Name: "vfork"}, {ID: 231, Name: "exit_group"}})
+}
+
+func TestParseDump_CallingConventions(t *testing.T) {
+	assertThat := func(assumption, fileName string, convention callingConvention, expected []uint16) {
+		should := should.New(t)
+		f, err := os.Open(fileName)
+		should.NotError(err, assumption)
+		defer f.Close()
+
+		symbols := parseDump(f, archs["amd64"], convention)
+
+		should.BeEqual(expected, symbols["syscall.Getpid"].syscallIDs, assumption)
+	}
+
+	assertThat("should use stack slots for older toolchains", "../../test/go1.16-syscalls.dump", stackConvention, []uint16{39})
+	assertThat("should use registers for newer toolchains", "../../test/go1.21-syscalls.dump", registerConvention, []uint16{39})
+	assertThat("should accept both when the go version is unknown", "../../test/go1.21-syscalls.dump", unknownConvention, []uint16{39})
+	assertThat("should not use registers for older toolchains", "../../test/go1.21-syscalls.dump", stackConvention, []uint16{})
+}
+
+func TestGetCallingConvention(t *testing.T) {
+	assertThat := func(assumption, arch, goVersion string, expected callingConvention) {
+		should := should.New(t)
+
+		actual := getCallingConvention(archs[arch], goVersion)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should use stack before go 1.17 on amd64", "amd64", "go1.16.15", stackConvention)
+	assertThat("should use registers since go 1.17 on amd64", "amd64", "go1.17", registerConvention)
+	assertThat("should use stack before go 1.18 on arm64", "arm64", "go1.17.13", stackConvention)
+	assertThat("should use registers since go 1.18 on arm64", "arm64", "go1.18.1", registerConvention)
+	assertThat("should use registers since go 1.19 on riscv64", "riscv64", "go1.19", registerConvention)
+	assertThat("should always use stack on 386", "386", "go1.21.0", stackConvention)
+	assertThat("should be unknown without go version", "amd64", "", unknownConvention)
+}
+
+func TestArgLocations(t *testing.T) {
+	assertThat := func(assumption string, convention callingConvention, pos int, expected []string) {
+		should := should.New(t)
+
+		actual := convention.argLocations(archs["amd64"], pos)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should return stack slot", stackConvention, 1, []string{"0x8(SP)"})
+	assertThat("should return register", registerConvention, 1, []string{"BX"})
+	assertThat("should return both when unknown", unknownConvention, 0, []string{"AX", "0(SP)"})
+	assertThat("should return stack slot when out of registers", unknownConvention, 9, []string{"0x48(SP)"})
+}
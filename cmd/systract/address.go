@@ -0,0 +1,98 @@
+package systract
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// addressRange is the range of instruction addresses of a TEXT symbol,
+// from its first instruction to its last one, both included.
+type addressRange struct {
+	name       string
+	start, end uint64
+}
+
+// addressRanges looks up TEXT symbols by the address of their instructions.
+type addressRanges []addressRange
+
+// add records the range of a symbol, ignoring symbols without instruction addresses.
+func (r *addressRanges) add(name string, start, end uint64) {
+	if start == 0 || end < start {
+		return
+	}
+
+	*r = append(*r, addressRange{name: name, start: start, end: end})
+}
+
+// sort orders the ranges by address, which symbolAt relies on.
+func (r addressRanges) sort() {
+	sort.Slice(r, func(i, j int) bool {
+		return r[i].start < r[j].start
+	})
+}
+
+// symbolAt returns the name of the symbol containing the address, or an
+// empty string when there is none.
+func (r addressRanges) symbolAt(addr uint64) (string, error) {
+	i := sort.Search(len(r), func(i int) bool { return addr < r[i].start })
+	if i > 0 && addr <= r[i-1].end {
+		return r[i-1].name, nil
+	}
+
+	return "", nil
+}
+
+// getAddress returns the address of a go tool objdump instruction line.
+func getAddress(assemblyLine string) uint64 {
+	captures := locationPattern.FindStringSubmatch(assemblyLine)
+	if captures == nil {
+		return 0
+	}
+
+	addr, _ := strconv.ParseUint(captures[3], 0, 64)
+	return addr
+}
+
+// isAddress checks whether the call target is an address instead of a symbol, e.g. 0x401000.
+func isAddress(target string) bool {
+	if !strings.HasPrefix(target, "0x") {
+		return false
+	}
+
+	_, err := strconv.ParseUint(target, 0, 64)
+	return err == nil
+}
+
+// linkAddressCalls replaces the addresses called by the symbol with the name of the
+// symbol containing them, so that they are followed as any other call. Dumps show calls
+// into the middle of functions as addresses, e.g. CALL 0x45441c for runtime.duffzero.
+// Addresses not within any symbol are kept, to be reported as unresolved.
+func linkAddressCalls(s *symbolDefinition, symbolAt func(addr uint64) (string, error)) error {
+	resolve := func(target string) (string, error) {
+		if !isAddress(target) {
+			return target, nil
+		}
+
+		addr, _ := strconv.ParseUint(target, 0, 64)
+		name, err := symbolAt(addr)
+		if err != nil || name == "" {
+			return target, err
+		}
+		return name, nil
+	}
+
+	var err error
+	for i := range s.subCalls {
+		if s.subCalls[i], err = resolve(s.subCalls[i]); err != nil {
+			return err
+		}
+	}
+	for i := range s.calls {
+		if s.calls[i].target, err = resolve(s.calls[i].target); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package systract
+
+import (
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestLinkAddressCalls(t *testing.T) {
+	should := should.New(t)
+	var addresses addressRanges
+	addresses.add("runtime.duffcopy", 0x454000, 0x454430)
+	addresses.add("main.main", 0x401000, 0x401080)
+	addresses.add("main.empty", 0, 0)
+	addresses.sort()
+
+	s := symbolDefinition{
+		subCalls: []string{"0x45441c", "0x401000", "main.f", "0x500000"},
+		calls:    []callArgs{{target: "0x454020"}, {target: "main.f"}},
+	}
+
+	err := linkAddressCalls(&s, addresses.symbolAt)
+
+	should.NotError(err, "should link address calls")
+	should.BeEqual([]string{"runtime.duffcopy", "main.main", "main.f", "0x500000"}, s.subCalls,
+		"should replace addresses within symbols with their names, keeping those outside of any symbol")
+	should.BeEqual("runtime.duffcopy", s.calls[0].target, "should replace addresses called with arguments")
+	should.BeEqual("main.f", s.calls[1].target, "should keep symbols called")
+}
+
+func TestIsAddress(t *testing.T) {
+	assertThat := func(assumption, target string, expected bool) {
+		should := should.New(t)
+
+		actual := isAddress(target)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should match hexadecimal addresses", "0x45441c", true)
+	assertThat("should not match symbols", "main.main", false)
+	assertThat("should not match invalid addresses", "0x45441z", false)
+}
@@ -0,0 +1,320 @@
+package systract
+
+import (
+	"bufio"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	amd64SyscallRegex   string = "\\bSYSCALL\\b"
+	arm64SyscallRegex   string = "SVC.\\$0"
+	i386SyscallRegex    string = "INT.\\$0x80"
+	armSyscallRegex     string = "(?:SVC|SWI).\\$0"
+	riscv64SyscallRegex string = "ECALL"
+	ppc64leSyscallRegex string = "\\bSYSCALL\\b|\\bSC.\\$0"
+	s390xSyscallRegex   string = "\\bSYSCALL\\b|\\bSYSALL\\b|\\bSVC\\b"
+
+	archHintRegex string = "_(amd64|arm64|386|arm|riscv64|ppc64x|ppc64le|s390x)\\.(?:s|go)\\b"
+	defaultArch   string = "amd64"
+)
+
+// archSpec describes how system calls are issued on a given architecture.
+type archSpec struct {
+	name        string
+	systemCalls map[uint16]string
+
+	// syscall matches instructions which issue a system call.
+	syscall *regexp.Regexp
+
+	// syscallMnemonics are the mnemonics of syscall instructions, one of which a line
+	// must contain before being matched against syscall.
+	syscallMnemonics []string
+
+	// stackArgs describes where arguments are stored by callers using the stack-based calling convention.
+	stackArgs stackArgs
+
+	// registerArgs are the integer argument registers of the register-based calling convention, in order.
+	registerArgs []string
+
+	// registerABISince is the go minor version which introduced the register-based
+	// calling convention for the architecture, zero when it is not supported.
+	registerABISince int
+
+	// trapRegister is the register holding the syscall number for syscall instructions.
+	trapRegister string
+
+	// zeroRegister is the register which always reads as zero, if any.
+	zeroRegister string
+
+	// callMnemonic is the mnemonic of direct calls.
+	callMnemonic string
+
+	// argLocations holds where the first maxForwardedArgs arguments of calls are stored
+	// for each calling convention, as they are looked up for every call.
+	argLocations [registerConvention + 1][][]string
+
+	// syscallWrappers are the syscall wrappers of the analysis, with the position of
+	// their trap argument. The wrappers registered are used when nil.
+	syscallWrappers map[string]int
+}
+
+// withSyscallWrappers returns a copy of the architecture handling calls to the wrappers
+// registered and to those provided as system calls.
+func (a *archSpec) withSyscallWrappers(wrappers []SyscallWrapper) *archSpec {
+	spec := *a
+	spec.syscallWrappers = newSyscallWrappers(append(registeredSyscallWrappers(), wrappers...))
+	return &spec
+}
+
+// syscallWrapper returns the position of the argument carrying the
+// syscall number when the symbol is a syscall wrapper of the analysis.
+func (a *archSpec) syscallWrapper(symbol string) (int, bool) {
+	if a.syscallWrappers == nil {
+		return getSyscallWrapper(symbol)
+	}
+
+	trapArg, found := a.syscallWrappers[symbol]
+	return trapArg, found
+}
+
+var archs = map[string]*archSpec{
+	"amd64": {
+		name:             "amd64",
+		systemCalls:      systemCalls,
+		syscall:          regexp.MustCompile(amd64SyscallRegex),
+		syscallMnemonics: []string{"SYSCALL"},
+		callMnemonic:     "CALL",
+		stackArgs:        stackArgs{register: "SP", size: 8, hex: true},
+		registerArgs:     []string{"AX", "BX", "CX", "DI", "SI", "R8", "R9", "R10", "R11"},
+		registerABISince: 17,
+		trapRegister:     "AX",
+	},
+	"arm64": {
+		name:             "arm64",
+		systemCalls:      arm64SystemCalls,
+		syscall:          regexp.MustCompile(arm64SyscallRegex),
+		syscallMnemonics: []string{"SVC"},
+		callMnemonic:     "CALL",
+		stackArgs:        stackArgs{register: "RSP", base: 8, size: 8},
+		registerArgs:     []string{"R0", "R1", "R2", "R3", "R4", "R5", "R6", "R7", "R8", "R9", "R10", "R11", "R12", "R13", "R14", "R15"},
+		registerABISince: 18,
+		trapRegister:     "R8",
+		zeroRegister:     "ZR",
+	},
+	"386": {
+		name:             "386",
+		systemCalls:      i386SystemCalls,
+		syscall:          regexp.MustCompile(i386SyscallRegex),
+		syscallMnemonics: []string{"INT"},
+		callMnemonic:     "CALL",
+		stackArgs:        stackArgs{register: "SP", size: 4, hex: true},
+		trapRegister:     "AX",
+	},
+	"arm": {
+		name:             "arm",
+		systemCalls:      armSystemCalls,
+		syscall:          regexp.MustCompile(armSyscallRegex),
+		syscallMnemonics: []string{"SVC", "SWI"},
+		callMnemonic:     "BL",
+		stackArgs:        stackArgs{register: "R13", base: 4, size: 4},
+		trapRegister:     "R7",
+	},
+	"riscv64": {
+		name:             "riscv64",
+		systemCalls:      riscv64SystemCalls,
+		syscall:          regexp.MustCompile(riscv64SyscallRegex),
+		syscallMnemonics: []string{"ECALL"},
+		callMnemonic:     "CALL",
+		stackArgs:        stackArgs{register: "X2", base: 8, size: 8},
+		registerArgs:     []string{"X10", "X11", "X12", "X13", "X14", "X15", "X16", "X17", "X8", "X9", "X18", "X19", "X20", "X21", "X22", "X23"},
+		registerABISince: 19,
+		trapRegister:     "X17",
+		zeroRegister:     "X0",
+	},
+	"ppc64le": {
+		name:             "ppc64le",
+		systemCalls:      ppc64leSystemCalls,
+		syscall:          regexp.MustCompile(ppc64leSyscallRegex),
+		syscallMnemonics: []string{"SYSCALL", "SC"},
+		callMnemonic:     "CALL",
+		stackArgs:        stackArgs{register: "R1", base: 32, size: 8},
+		registerArgs:     []string{"R3", "R4", "R5", "R6", "R7", "R8", "R9", "R10", "R14", "R15", "R16", "R17"},
+		registerABISince: 18,
+		trapRegister:     "R0",
+	},
+	"s390x": {
+		name:             "s390x",
+		systemCalls:      s390xSystemCalls,
+		syscall:          regexp.MustCompile(s390xSyscallRegex),
+		syscallMnemonics: []string{"SYSCALL", "SYSALL", "SVC"},
+		callMnemonic:     "CALL",
+		stackArgs:        stackArgs{register: "R15", base: 8, size: 8},
+		trapRegister:     "R1",
+	},
+}
+
+func init() {
+	for _, arch := range archs {
+		for c := range arch.argLocations {
+			for pos := 0; pos < maxForwardedArgs; pos++ {
+				arch.argLocations[c] = append(arch.argLocations[c], callingConvention(c).locations(arch, pos))
+			}
+		}
+	}
+}
+
+// callTarget returns the function called by direct calls, e.g. main.main for CALL main.main(SB)
+// or 0x45441c for CALL 0x45441c, and an empty string for all other instructions.
+func (a *archSpec) callTarget(inst instruction) string {
+	if inst.op != a.callMnemonic {
+		return ""
+	}
+
+	// the operand is taken as a whole, as the type arguments of generic instantiations may
+	// contain commas and spaces, e.g. CALL maps.Keys[go.shape.map[string]int,go.shape.string](SB).
+	operand := strings.TrimSpace(inst.text[len(inst.op):])
+	if isAddress(operand) {
+		return operand
+	}
+	if name := strings.TrimSuffix(operand, "(SB)"); name != "" && len(name) < len(operand) {
+		return trimOffset(name)
+	}
+
+	return ""
+}
+
+// stackArgs describes the stack slots holding call arguments, e.g. 0(SP), 0x8(SP) on amd64.
+type stackArgs struct {
+	register   string
+	base, size int
+	// hex sets whether offsets are shown in hexadecimal.
+	hex bool
+}
+
+// slot returns the operand referencing the argument at the zero-based position pos.
+func (a stackArgs) slot(pos int) string {
+	offset := a.base + pos*a.size
+	if a.hex && offset != 0 {
+		return fmt.Sprintf("%#x(%s)", offset, a.register)
+	}
+
+	return fmt.Sprintf("%d(%s)", offset, a.register)
+}
+
+// isSyscall checks whether the instruction issues a system call. The regular
+// expression is only run on lines containing one of the syscall mnemonics.
+func (a *archSpec) isSyscall(assemblyLine string) bool {
+	for _, mnemonic := range a.syscallMnemonics {
+		if strings.Contains(assemblyLine, mnemonic) {
+			return a.syscall.MatchString(assemblyLine)
+		}
+	}
+
+	return false
+}
+
+// archSource is implemented by source readers that are able to
+// tell the target architecture of their input.
+type archSource interface {
+	arch() (string, error)
+}
+
+// getArch returns the architecture of the source, defaulting to amd64
+// for sources which cannot determine it.
+func getArch(source SourceReader) (*archSpec, error) {
+	name := defaultArch
+	if s, ok := source.(archSource); ok {
+		detected, err := s.arch()
+		if err != nil {
+			return nil, err
+		}
+		name = detected
+	}
+
+	arch, found := archs[name]
+	if !found {
+		return nil, fmt.Errorf("unsupported architecture: %s", name)
+	}
+
+	return arch, nil
+}
+
+// getELFArch returns the go architecture name based on the elf header of the file.
+func getELFArch(filePath string) (string, error) {
+	f, err := elf.Open(filePath)
+	if err != nil {
+		return "", errors.Wrap(err, "could not open elf file")
+	}
+	defer f.Close()
+
+	return elfArch(f), nil
+}
+
+func elfArch(f *elf.File) string {
+	switch f.Machine {
+	case elf.EM_386:
+		return "386"
+	case elf.EM_X86_64:
+		return "amd64"
+	case elf.EM_ARM:
+		return "arm"
+	case elf.EM_AARCH64:
+		return "arm64"
+	case elf.EM_PPC64:
+		if f.ByteOrder == binary.LittleEndian {
+			return "ppc64le"
+		}
+		return "ppc64"
+	case elf.EM_RISCV:
+		if f.Class == elf.ELFCLASS64 {
+			return "riscv64"
+		}
+	case elf.EM_S390:
+		return "s390x"
+	}
+
+	return f.Machine.String()
+}
+
+// detectDumpArch returns the architecture of a go tool objdump output,
+// based on the architecture specific source files referenced by it.
+func detectDumpArch(reader io.Reader) (string, error) {
+	re := regexp.MustCompile(archHintRegex)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		captures := re.FindStringSubmatch(scanner.Text())
+		if len(captures) > 0 {
+			// ppc64x files are shared by both endiannesses,
+			// only the little-endian variant is supported.
+			if captures[1] == "ppc64x" {
+				return "ppc64le", nil
+			}
+			return captures[1], nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return defaultArch, nil
+}
+
+func getDumpArch(filePath string) (string, error) {
+	/* #nosec filePath is pre-processed by sanitiseFileName */
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	return detectDumpArch(f)
+}
@@ -0,0 +1,126 @@
+package systract
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+type stubSourceReader struct{}
+
+func (s *stubSourceReader) GetReader() (io.ReadCloser, error) {
+	return ioutil.NopCloser(strings.NewReader("")), nil
+}
+
+// writeELFHeader creates a file containing only the elf header for the given machine.
+func writeELFHeader(t *testing.T, machine elf.Machine) string {
+	header := elf.Header64{
+		Type:      uint16(elf.ET_EXEC),
+		Machine:   uint16(machine),
+		Version:   uint32(elf.EV_CURRENT),
+		Ehsize:    64,
+		Phentsize: 56,
+		Shentsize: 64,
+	}
+	copy(header.Ident[:], elf.ELFMAG)
+	header.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	header.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	header.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, header); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := ioutil.TempFile("", "elf-header.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	return f.Name()
+}
+
+func TestGetArch(t *testing.T) {
+	assertThat := func(assumption string, source SourceReader, expected string, expectedErr bool) {
+		should := should.New(t)
+
+		arch, err := getArch(source)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		if !expectedErr {
+			should.BeEqual(expected, arch.name, assumption)
+		}
+	}
+
+	arm64Exe := writeELFHeader(t, elf.EM_AARCH64)
+	defer os.Remove(arm64Exe)
+	i386Exe := writeELFHeader(t, elf.EM_386)
+	defer os.Remove(i386Exe)
+	armExe := writeELFHeader(t, elf.EM_ARM)
+	defer os.Remove(armExe)
+	riscv64Exe := writeELFHeader(t, elf.EM_RISCV)
+	defer os.Remove(riscv64Exe)
+	ppc64leExe := writeELFHeader(t, elf.EM_PPC64)
+	defer os.Remove(ppc64leExe)
+	s390xExe := writeELFHeader(t, elf.EM_S390)
+	defer os.Remove(s390xExe)
+	mipsExe := writeELFHeader(t, elf.EM_MIPS)
+	defer os.Remove(mipsExe)
+
+	assertThat("should default to amd64 for sources which cannot detect architecture",
+		&stubSourceReader{}, "amd64", false)
+	assertThat("should detect amd64 from executables", NewELFReader("../../test/simple-app"), "amd64", false)
+	assertThat("should detect arm64 from executables", NewELFReader(arm64Exe), "arm64", false)
+	assertThat("should detect arm64 from executables handled by go tool objdump", NewExeReader(arm64Exe), "arm64", false)
+	assertThat("should detect 386 from executables", NewELFReader(i386Exe), "386", false)
+	assertThat("should detect arm from executables", NewELFReader(armExe), "arm", false)
+	assertThat("should detect riscv64 from executables", NewELFReader(riscv64Exe), "riscv64", false)
+	assertThat("should detect ppc64le from executables", NewELFReader(ppc64leExe), "ppc64le", false)
+	assertThat("should detect s390x from executables", NewELFReader(s390xExe), "s390x", false)
+	assertThat("should detect amd64 from dump files", NewDumpReader("../../test/single-syscall.dump"), "amd64", false)
+	assertThat("should detect arm64 from dump files", NewDumpReader("../../test/arm64-single-syscall.dump"), "arm64", false)
+	assertThat("should detect 386 from dump files", NewDumpReader("../../test/386-single-syscall.dump"), "386", false)
+	assertThat("should detect arm from dump files", NewDumpReader("../../test/arm-single-syscall.dump"), "arm", false)
+	assertThat("should detect riscv64 from dump files", NewDumpReader("../../test/riscv64-single-syscall.dump"), "riscv64", false)
+	assertThat("should detect ppc64le from dump files", NewDumpReader("../../test/ppc64le-single-syscall.dump"), "ppc64le", false)
+	assertThat("should detect s390x from dump files", NewDumpReader("../../test/s390x-single-syscall.dump"), "s390x", false)
+	assertThat("should error for unsupported architectures", NewELFReader(mipsExe), "", true)
+	assertThat("should error for files that are not executables", NewELFReader("../../test/single-syscall.dump"), "", true)
+}
+
+func TestDetectDumpArch(t *testing.T) {
+	assertThat := func(assumption, dump, expected string) {
+		should := should.New(t)
+
+		actual, err := detectDumpArch(strings.NewReader(dump))
+
+		should.NotError(err, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should detect arch from symbol definitions",
+		"TEXT runtime.exit.abi0(SB) /usr/local/go/src/runtime/sys_linux_arm64.s", "arm64")
+	assertThat("should detect arch from instruction source files",
+		"TEXT main.main(SB) /app/main.go\n  sys_linux_amd64.s:52	0x47f844		b8e7000000		MOVL $0xe7, AX", "amd64")
+	assertThat("should not confuse arm with arm64",
+		"TEXT runtime.exit(SB) /usr/local/go/src/runtime/sys_linux_arm.s", "arm")
+	assertThat("should detect ppc64le from files shared with ppc64",
+		"TEXT runtime.exit.abi0(SB) /usr/local/go/src/runtime/sys_linux_ppc64x.s", "ppc64le")
+	assertThat("should detect arch from go source files",
+		"TEXT internal/cpu.doinit(SB) /usr/local/go/src/internal/cpu/cpu_arm64.go", "arm64")
+	assertThat("should default to amd64 when no hints are found",
+		"TEXT main.main(SB) /app/main.go", "amd64")
+}
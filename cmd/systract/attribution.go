@@ -0,0 +1,210 @@
+package systract
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// stdModule is the module used for packages of the go standard library.
+const stdModule string = "std"
+
+// PackageSyscalls represents the system calls issued by code within a package.
+type PackageSyscalls struct {
+	Package string `json:"package"`
+	// Module is the module path the package belongs to, empty when unknown.
+	Module      string       `json:"module,omitempty"`
+	SystemCalls []SystemCall `json:"systemCalls"`
+}
+
+// ModuleSyscalls represents the system calls issued by code within a module.
+type ModuleSyscalls struct {
+	Module      string            `json:"module"`
+	Packages    []PackageSyscalls `json:"packages"`
+	SystemCalls []SystemCall      `json:"systemCalls"`
+}
+
+// moduleSource is implemented by source readers that are able to
+// tell the modules their input was built with, the main module first.
+type moduleSource interface {
+	modules() ([]string, error)
+}
+
+// getModules returns the module paths of the source, or nil when it cannot be determined.
+func getModules(source SourceReader) []string {
+	if s, ok := source.(moduleSource); ok {
+		if modules, err := s.modules(); err == nil {
+			return modules
+		}
+	}
+
+	return nil
+}
+
+// attributeSyscalls groups the syscalls issued by the reachable symbols by their
+// package, sorted by package.
+func attributeSyscalls(symbols map[string]symbolDefinition, arch *archSpec, reachable, modules []string) []PackageSyscalls {
+	byPackage := make(map[string]map[uint16]bool)
+	for _, name := range reachable {
+		s := symbols[name]
+		if len(s.syscallIDs) == 0 {
+			continue
+		}
+
+		pkg := packagePath(name)
+		if _, exists := byPackage[pkg]; !exists {
+			byPackage[pkg] = make(map[uint16]bool)
+		}
+		for _, id := range s.syscallIDs {
+			byPackage[pkg][id] = true
+		}
+	}
+
+	packages := make([]PackageSyscalls, 0, len(byPackage))
+	for pkg, ids := range byPackage {
+		packages = append(packages, PackageSyscalls{
+			Package:     pkg,
+			Module:      modulePath(pkg, modules),
+			SystemCalls: toSystemCalls(ids, arch),
+		})
+	}
+
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].Package < packages[j].Package
+	})
+
+	return packages
+}
+
+// GroupByModule groups the package syscalls by their module, sorted by module.
+// Packages without a known module are grouped by their own path.
+func GroupByModule(packages []PackageSyscalls) []ModuleSyscalls {
+	indexes := make(map[string]int)
+	modules := make([]ModuleSyscalls, 0)
+
+	for _, p := range packages {
+		module := p.Module
+		if module == "" {
+			module = p.Package
+		}
+
+		i, exists := indexes[module]
+		if !exists {
+			i = len(modules)
+			indexes[module] = i
+			modules = append(modules, ModuleSyscalls{Module: module})
+		}
+
+		modules[i].Packages = append(modules[i].Packages, p)
+		modules[i].SystemCalls = append(modules[i].SystemCalls, p.SystemCalls...)
+	}
+
+	for i := range modules {
+		modules[i].SystemCalls = uniqueSyscalls(modules[i].SystemCalls)
+	}
+
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].Module < modules[j].Module
+	})
+
+	return modules
+}
+
+// packagePath returns the package path of a go symbol name, e.g.
+// github.com/pkg/errors for github.com/pkg/errors.(*fundamental).Error.
+// Symbol names escape dots in the last element of package paths, e.g.
+// gopkg.in/yaml%2ev3.Unmarshal, which are unescaped.
+func packagePath(symbol string) string {
+	name := symbol
+	if i := strings.IndexAny(name, "[("); i >= 0 {
+		name = name[:i]
+	}
+
+	slash := strings.LastIndex(name, "/")
+	if i := strings.Index(name[slash+1:], "."); i >= 0 {
+		return unescapePackagePath(symbol[:slash+1+i])
+	}
+
+	return symbol
+}
+
+// unescapePackagePath reverts the escaping of package paths within symbol names,
+// returning paths which are not validly escaped as is.
+func unescapePackagePath(pkg string) string {
+	if !strings.Contains(pkg, "%") {
+		return pkg
+	}
+
+	if unescaped, err := url.PathUnescape(pkg); err == nil {
+		return unescaped
+	}
+
+	return pkg
+}
+
+// modulePath returns the longest module path containing the package.
+// The main package is attributed to the main module.
+func modulePath(pkg string, modules []string) string {
+	if pkg == "main" && len(modules) > 0 {
+		return modules[0]
+	}
+
+	module := ""
+	for _, m := range modules {
+		if (pkg == m || strings.HasPrefix(pkg, m+"/")) && len(m) > len(module) {
+			module = m
+		}
+	}
+
+	if module == "" && isStdPackage(pkg) {
+		return stdModule
+	}
+
+	return module
+}
+
+// isStdPackage checks whether the package belongs to the go standard library,
+// as their first path element does not contain a dot.
+func isStdPackage(pkg string) bool {
+	if pkg == "main" {
+		return false
+	}
+
+	first := pkg
+	if i := strings.Index(pkg, "/"); i >= 0 {
+		first = pkg[:i]
+	}
+
+	return !strings.Contains(first, ".")
+}
+
+func toSystemCalls(ids map[uint16]bool, arch *archSpec) []SystemCall {
+	syscalls := make([]SystemCall, 0, len(ids))
+	for id := range ids {
+		syscalls = append(syscalls, SystemCall{ID: id, Name: arch.systemCalls[id]})
+	}
+
+	sort.Slice(syscalls, func(i, j int) bool {
+		return syscalls[i].Name < syscalls[j].Name
+	})
+
+	return syscalls
+}
+
+// uniqueSyscalls removes duplicated syscalls, sorting them by name.
+func uniqueSyscalls(syscalls []SystemCall) []SystemCall {
+	unique := make(map[uint16]bool, len(syscalls))
+	result := make([]SystemCall, 0, len(syscalls))
+	for _, s := range syscalls {
+		if !unique[s.ID] {
+			unique[s.ID] = true
+			result = append(result, s)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
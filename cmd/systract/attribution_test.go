@@ -0,0 +1,105 @@
+package systract
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestPackagePath(t *testing.T) {
+	assertThat := func(assumption, symbol, expected string) {
+		should := should.New(t)
+
+		actual := packagePath(symbol)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should return package of functions", "syscall.Syscall", "syscall")
+	assertThat("should return package of nested paths", "internal/syscall/unix.IsNonblock", "internal/syscall/unix")
+	assertThat("should return package of methods", "github.com/pkg/errors.(*fundamental).Error", "github.com/pkg/errors")
+	assertThat("should return package of dotted module paths", "github.com/jsipprell/keyctl.add_key", "github.com/jsipprell/keyctl")
+	assertThat("should return package of closures", "os.(*File).Write.func1", "os")
+	assertThat("should ignore paths within generic type arguments", "slices.Sort[go.shape.[]github.com/a/b.T]", "slices")
+	assertThat("should return package of abi wrappers", "runtime.exit.abi0", "runtime")
+	assertThat("should unescape dots in the last path element", "gopkg.in/yaml%2ev3.Unmarshal", "gopkg.in/yaml.v3")
+	assertThat("should unescape dots of methods", "gopkg.in/yaml%2ev3.(*decoder).unmarshal", "gopkg.in/yaml.v3")
+	assertThat("should return symbol when it has no package", "_rt0_amd64_linux", "_rt0_amd64_linux")
+}
+
+func TestModulePath(t *testing.T) {
+	modules := []string{"github.com/acme/app", "github.com/jsipprell/keyctl", "golang.org/x/sys", "golang.org/x/sys/unix/sub", "gopkg.in/yaml.v3"}
+
+	assertThat := func(assumption, pkg string, modules []string, expected string) {
+		should := should.New(t)
+
+		actual := modulePath(pkg, modules)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should attribute module root packages", "github.com/jsipprell/keyctl", modules, "github.com/jsipprell/keyctl")
+	assertThat("should attribute nested packages", "golang.org/x/sys/unix", modules, "golang.org/x/sys")
+	assertThat("should prefer longest module path", "golang.org/x/sys/unix/sub/pkg", modules, "golang.org/x/sys/unix/sub")
+	assertThat("should attribute gopkg.in modules", packagePath("gopkg.in/yaml%2ev3.Unmarshal"), modules, "gopkg.in/yaml.v3")
+	assertThat("should not match partial path elements", "github.com/acme/application", modules, "")
+	assertThat("should attribute main package to main module", "main", modules, "github.com/acme/app")
+	assertThat("should attribute standard library packages", "internal/poll", modules, "std")
+	assertThat("should attribute standard library packages without modules", "runtime", nil, "std")
+	assertThat("should return empty for unknown modules", "github.com/jsipprell/keyctl", nil, "")
+	assertThat("should return empty for main package without modules", "main", nil, "")
+}
+
+func TestAttributeSyscalls(t *testing.T) {
+	should := should.New(t)
+	symbols := map[string]symbolDefinition{
+		"main.main": {subCalls: []string{"github.com/jsipprell/keyctl.(*keyring).Add", "os.Exit"}},
+		"github.com/jsipprell/keyctl.(*keyring).Add": {subCalls: []string{"github.com/jsipprell/keyctl.add_key", "github.com/jsipprell/keyctl.keyctl"}},
+		"github.com/jsipprell/keyctl.add_key":        {syscallIDs: []uint16{248}, subCalls: []string{"syscall.Syscall"}},
+		"github.com/jsipprell/keyctl.keyctl":         {syscallIDs: []uint16{250}, subCalls: []string{"syscall.Syscall"}},
+		"os.Exit":                                    {subCalls: []string{"runtime.exit"}},
+		"runtime.exit":                               {syscallIDs: []uint16{231}},
+		"unreachable.Func":                           {syscallIDs: []uint16{101}},
+	}
+
+	actual := attributeSyscalls(symbols, archs["amd64"], newCallGraph(symbols).reachableSymbols(getEntryPoints(symbols, nil)), []string{"github.com/acme/app", "github.com/jsipprell/keyctl"})
+
+	should.BeEqual([]PackageSyscalls{
+		{Package: "github.com/jsipprell/keyctl", Module: "github.com/jsipprell/keyctl",
+			SystemCalls: []SystemCall{{ID: 248, Name: "add_key"}, {ID: 250, Name: "keyctl"}}},
+		{Package: "runtime", Module: "std", SystemCalls: []SystemCall{{ID: 231, Name: "exit_group"}}},
+	}, actual, "should group reachable syscalls by package")
+}
+
+func TestGroupByModule(t *testing.T) {
+	should := should.New(t)
+	packages := []PackageSyscalls{
+		{Package: "github.com/jsipprell/keyctl", Module: "github.com/jsipprell/keyctl", SystemCalls: []SystemCall{{ID: 248, Name: "add_key"}}},
+		{Package: "runtime", Module: "std", SystemCalls: []SystemCall{{ID: 231, Name: "exit_group"}, {ID: 1, Name: "write"}}},
+		{Package: "syscall", Module: "std", SystemCalls: []SystemCall{{ID: 1, Name: "write"}, {ID: 0, Name: "read"}}},
+		{Package: "main", SystemCalls: []SystemCall{{ID: 39, Name: "getpid"}}},
+	}
+
+	actual := GroupByModule(packages)
+
+	should.BeEqual([]ModuleSyscalls{
+		{Module: "github.com/jsipprell/keyctl", Packages: packages[0:1], SystemCalls: []SystemCall{{ID: 248, Name: "add_key"}}},
+		{Module: "main", Packages: packages[3:4], SystemCalls: []SystemCall{{ID: 39, Name: "getpid"}}},
+		{Module: "std", Packages: packages[1:3],
+			SystemCalls: []SystemCall{{ID: 231, Name: "exit_group"}, {ID: 0, Name: "read"}, {ID: 1, Name: "write"}}},
+	}, actual, "should group packages by module, falling back to the package path")
+}
+
+func TestAnalyse_Packages(t *testing.T) {
+	should := should.New(t)
+	filePath, _ := filepath.Abs("../../test/simple-app")
+
+	result, err := Analyse(NewELFReader(filePath))
+
+	should.NotError(err, "should analyse executable")
+	should.BeEqual(3, len(result.Packages), "should attribute syscalls to all packages")
+	should.BeEqual(PackageSyscalls{Package: "syscall", Module: "std",
+		SystemCalls: []SystemCall{{ID: 72, Name: "fcntl"}, {ID: 267, Name: "readlinkat"}, {ID: 1, Name: "write"}}},
+		result.Packages[2], "should attribute syscalls to std packages")
+}
@@ -0,0 +1,470 @@
+package systract
+
+import (
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// BoundedSource wraps a source so that its analysis does not hold all of its symbols in
+// memory. Parsed symbols are spilled to an on-disk index, over which the call graph is
+// walked, loading each symbol when visited and dropping it once the syscall set of its
+// component is computed. Memory therefore grows with the depth of the call graph and the
+// syscall sites found, rather than with the symbols of the source. Commands needing all
+// reachable symbols at once, e.g. saving symbol graphs, still load them into memory.
+type BoundedSource struct {
+	sourceWrapper
+	// tempDir is where the index is created, defaulting to the directory for temporary files.
+	tempDir string
+}
+
+// NewBoundedSource initialises a new BoundedSource, keeping its on-disk index in tempDir,
+// or in the directory for temporary files when empty.
+func NewBoundedSource(source SourceReader, tempDir string) *BoundedSource {
+	return &BoundedSource{sourceWrapper: sourceWrapper{source}, tempDir: tempDir}
+}
+
+// analyse analyses the source walking the call graph over its on-disk index. Sources
+// holding parsed symbols already have them all in memory, and are analysed as they are.
+func (b *BoundedSource) analyse() (*Result, error) {
+	if _, ok := unwrapSource(b).(symbolSource); ok {
+		return analyseSymbols(b)
+	}
+
+	reader, arch, err := openSource(b)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	goVersion := getGoVersion(b)
+	index, entryPoints, roots, err := indexDump(reader, arch, getCallingConvention(arch, goVersion), goVersion, b.tempDir)
+	if err != nil {
+		return nil, err
+	}
+	defer index.close()
+
+	walk := newBoundedWalk(index, arch)
+	syscalls, err := walk.extractSyscalls(entryPoints)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols := walk.results
+	reachable := make([]string, 0, len(symbols))
+	for name := range symbols {
+		reachable = append(reachable, name)
+	}
+	sort.Strings(reachable)
+	addSyscallSites(syscalls, symbols, reachable)
+
+	return &Result{
+		Arch:         arch.name,
+		GoVersion:    goVersion,
+		SystemCalls:  syscalls,
+		Packages:     attributeSyscalls(symbols, arch, reachable, getModules(b)),
+		RuntimeRoots: roots,
+		Confidence:   syscallConfidence(symbols, arch, reachable),
+		Unresolved:   findUnresolved(symbols, reachable),
+	}, nil
+}
+
+// parseDump parses the dump into the on-disk index of the source, returning the
+// symbols reachable from the entry points.
+func (b *BoundedSource) parseDump(reader io.Reader, arch *archSpec, convention callingConvention, goVersion string) (map[string]symbolDefinition, error) {
+	return parseDumpBounded(reader, arch, convention, goVersion, b.tempDir)
+}
+
+// parseDumpBounded parses the symbols of the dump into an on-disk index, returning
+// the symbols reachable from the entry points, linked as parseDump would.
+func parseDumpBounded(reader io.Reader, arch *archSpec, convention callingConvention, goVersion, tempDir string) (map[string]symbolDefinition, error) {
+	index, entryPoints, _, err := indexDump(reader, arch, convention, goVersion, tempDir)
+	if err != nil {
+		return nil, err
+	}
+	defer index.close()
+
+	return loadReachable(index, entryPoints, arch)
+}
+
+// indexDump parses the symbols of the dump into a new on-disk index, returning it
+// alongside the entry points and the runtime roots among them.
+func indexDump(reader io.Reader, arch *archSpec, convention callingConvention, goVersion, tempDir string) (*symbolIndex, []string, []string, error) {
+	index, err := newSymbolIndex(tempDir)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// init functions are few, and needed to find the entry points.
+	entryCandidates := make(map[string]symbolDefinition)
+	err = parseSymbols(reader, arch, convention, runtime.GOMAXPROCS(0), func(p parsedSymbol) error {
+		if p.kept && isInitSymbol(p.name) {
+			entryCandidates[p.name] = symbolDefinition{}
+		}
+		return index.addSymbol(p)
+	})
+	if err != nil {
+		index.close()
+		return nil, nil, nil, err
+	}
+
+	for _, r := range runtimeRoots {
+		for _, name := range []string{r.symbol, r.symbol + abi0Suffix} {
+			_, kept, _, err := index.symbol(name)
+			if err != nil {
+				index.close()
+				return nil, nil, nil, err
+			}
+			if kept {
+				entryCandidates[name] = symbolDefinition{}
+			}
+		}
+	}
+
+	roots := findRuntimeRoots(entryCandidates, arch.name, goVersion)
+	return index, getEntryPoints(entryCandidates, roots), roots, nil
+}
+
+// loadReachable loads the symbols reachable from the entry points from the index,
+// propagating syscall arguments and linking unresolved calls as parseDump would.
+func loadReachable(index *symbolIndex, entryPoints []string, arch *archSpec) (map[string]symbolDefinition, error) {
+	symbols := make(map[string]symbolDefinition)
+	textSymbols := make(map[string]bool)
+	visited := make(map[string]bool)
+
+	pending := append([]string{}, entryPoints...)
+	for len(pending) > 0 {
+		name := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if visited[name] {
+			continue
+		}
+		visited[name] = true
+
+		s, kept, text, err := loadSymbol(index, name)
+		if err != nil {
+			return nil, err
+		}
+		if text {
+			textSymbols[name] = true
+		}
+		if !kept {
+			continue
+		}
+
+		symbols[name] = s
+		pending = append(pending, s.subCalls...)
+		for _, call := range s.calls {
+			pending = append(pending, call.target)
+		}
+	}
+
+	propagateSyscallArgs(symbols, arch)
+	linkUnresolvedCalls(symbols, textSymbols, arch)
+
+	return symbols, nil
+}
+
+// loadSymbol loads a symbol from the index, linking the addresses it calls, the methods
+// of the types it uses and the functions it references as parseDump would.
+func loadSymbol(index *symbolIndex, name string) (s symbolDefinition, kept bool, text bool, err error) {
+	s, kept, text, err = index.symbol(name)
+	if err != nil || !kept {
+		return
+	}
+	if err = linkAddressCalls(&s, index.symbolAt); err != nil {
+		return
+	}
+
+	for _, t := range s.types {
+		methods, err := index.methods(strings.TrimPrefix(t, "*"))
+		if err != nil {
+			return s, kept, text, err
+		}
+		s.subCalls = append(s.subCalls, methods...)
+	}
+	for _, r := range s.references {
+		_, found, _, err := index.symbol(r)
+		if err != nil {
+			return s, kept, text, err
+		}
+		if found && r != name {
+			s.subCalls = append(s.subCalls, r)
+		}
+	}
+
+	return
+}
+
+// boundedWalk walks the call graph of the symbols of an index as callGraph does,
+// loading each symbol when visited. Once a component is completed, the syscall
+// arguments of its members are propagated and their calls linked, after which
+// their syscall sets and parameters are stored in the index and their definitions
+// dropped. Only what results report of each symbol is kept, i.e. its syscall sites,
+// confidence and unresolved calls.
+type boundedWalk struct {
+	index *symbolIndex
+	arch  *archSpec
+
+	// symbols, order and lowLink hold the symbols on the stack of the walk.
+	symbols map[string]symbolDefinition
+	order   map[string]int
+	lowLink map[string]int
+	stack   []string
+	visits  int
+
+	results map[string]symbolDefinition
+}
+
+func newBoundedWalk(index *symbolIndex, arch *archSpec) *boundedWalk {
+	return &boundedWalk{
+		index:   index,
+		arch:    arch,
+		symbols: make(map[string]symbolDefinition),
+		order:   make(map[string]int),
+		lowLink: make(map[string]int),
+		results: make(map[string]symbolDefinition),
+	}
+}
+
+// extractSyscalls returns the system calls reachable from the entry points, in the
+// order they are first reached.
+func (w *boundedWalk) extractSyscalls(entryPoints []string) ([]SystemCall, error) {
+	syscalls := make([]SystemCall, 0)
+	unique := make(map[uint16]bool)
+
+	for _, symbol := range entryPoints {
+		ids, err := w.reachableSyscalls(symbol)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range ids {
+			if _, exists := unique[id]; !exists {
+				unique[id] = true
+				syscalls = append(syscalls, SystemCall{
+					ID:   id,
+					Name: w.arch.systemCalls[id],
+				})
+			}
+		}
+	}
+
+	return syscalls, nil
+}
+
+// reachableSyscalls returns the sorted ids of all system calls reachable from the symbol.
+func (w *boundedWalk) reachableSyscalls(symbol string) ([]uint16, error) {
+	set, _, walked, err := w.index.walked(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	if !walked {
+		s, kept, _, err := loadSymbol(w.index, symbol)
+		if err != nil || !kept {
+			return nil, err
+		}
+		if err := w.visit(symbol, s); err != nil {
+			return nil, err
+		}
+		if set, _, _, err = w.index.walked(symbol); err != nil {
+			return nil, err
+		}
+	}
+
+	return w.index.syscallSet(set)
+}
+
+// visit walks the symbol following Tarjan's algorithm, completing each component
+// once all components it calls have been completed.
+func (w *boundedWalk) visit(symbol string, s symbolDefinition) error {
+	w.order[symbol] = w.visits
+	w.lowLink[symbol] = w.visits
+	w.visits++
+	w.stack = append(w.stack, symbol)
+	w.symbols[symbol] = s
+
+	for _, callee := range s.subCalls {
+		if order, onStack := w.order[callee]; onStack {
+			if order < w.lowLink[symbol] {
+				w.lowLink[symbol] = order
+			}
+			continue
+		}
+
+		_, _, walked, err := w.index.walked(callee)
+		if err != nil {
+			return err
+		}
+		if walked {
+			continue
+		}
+
+		c, kept, _, err := loadSymbol(w.index, callee)
+		if err != nil {
+			return err
+		}
+		if !kept {
+			continue
+		}
+
+		if err := w.visit(callee, c); err != nil {
+			return err
+		}
+		if lowLink, onStack := w.lowLink[callee]; onStack && lowLink < w.lowLink[symbol] {
+			w.lowLink[symbol] = lowLink
+		}
+	}
+
+	if w.lowLink[symbol] != w.order[symbol] {
+		return nil
+	}
+
+	// symbol is the root of its component, whose members sit above it on the stack.
+	i := len(w.stack) - 1
+	for w.stack[i] != symbol {
+		i--
+	}
+	members := append([]string{}, w.stack[i:]...)
+	w.stack = w.stack[:i]
+
+	return w.complete(members)
+}
+
+// complete propagates the syscall arguments passed by the members of a component and
+// links their calls, storing the syscall set of the component before dropping them.
+// Members are the only symbols called by the component which are yet to be stored.
+func (w *boundedWalk) complete(members []string) error {
+	var err error
+	callee := func(target string) ([]int, bool) {
+		if s, member := w.symbols[target]; member {
+			return s.params, true
+		}
+
+		_, params, walked, walkedErr := w.index.walked(target)
+		if walkedErr != nil || walked {
+			err = walkedErr
+			return params, walked
+		}
+
+		_, _, text, symbolErr := w.index.symbol(target)
+		err = symbolErr
+		return nil, text
+	}
+	params := func(target string) []int {
+		p, _ := callee(target)
+		return p
+	}
+
+	for changed := true; changed && err == nil; {
+		changed = false
+		for _, name := range members {
+			s := w.symbols[name]
+			if propagateCallArgs(&s, params, w.arch) {
+				w.symbols[name] = s
+				changed = true
+			}
+		}
+	}
+	for _, name := range members {
+		s := w.symbols[name]
+		addUnresolvedCalls(&s, callee, w.arch)
+		w.symbols[name] = s
+	}
+	if err != nil {
+		return err
+	}
+
+	set, err := w.componentSyscalls(members)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range members {
+		s := w.symbols[name]
+		if err := w.index.addWalked(name, set, s.params); err != nil {
+			return err
+		}
+
+		if len(s.syscallIDs) > 0 || len(s.unresolved) > 0 {
+			w.results[name] = symbolDefinition{
+				syscallIDs: s.syscallIDs,
+				sites:      s.sites,
+				confidence: s.confidence,
+				unresolved: s.unresolved,
+			}
+		}
+
+		delete(w.symbols, name)
+		delete(w.order, name)
+		delete(w.lowLink, name)
+	}
+
+	return nil
+}
+
+// componentSyscalls stores the syscalls issued by the members of a component merged with
+// the sets of the components they call, returning its reference. The set of a single
+// callee is referenced as is when the component adds nothing to it.
+func (w *boundedWalk) componentSyscalls(members []string) (int64, error) {
+	unique := make(map[uint16]bool)
+	var callees []int64
+
+	for _, member := range members {
+		s := w.symbols[member]
+		for _, id := range s.syscallIDs {
+			unique[id] = true
+		}
+
+		for _, callee := range s.subCalls {
+			if _, isMember := w.symbols[callee]; isMember {
+				continue
+			}
+
+			set, _, _, err := w.index.walked(callee)
+			if err != nil {
+				return 0, err
+			}
+			if set != 0 {
+				callees = append(callees, set)
+			}
+		}
+	}
+
+	sameSets := true
+	for _, set := range callees {
+		sameSets = sameSets && set == callees[0]
+	}
+	if len(unique) == 0 && sameSets {
+		if len(callees) == 0 {
+			return 0, nil
+		}
+		return callees[0], nil
+	}
+
+	merged := make(map[int64]bool)
+	for _, set := range callees {
+		if merged[set] {
+			continue
+		}
+		merged[set] = true
+
+		ids, err := w.index.syscallSet(set)
+		if err != nil {
+			return 0, err
+		}
+		for _, id := range ids {
+			unique[id] = true
+		}
+	}
+
+	syscalls := make([]uint16, 0, len(unique))
+	for id := range unique {
+		syscalls = append(syscalls, id)
+	}
+	sort.Slice(syscalls, func(i, j int) bool { return syscalls[i] < syscalls[j] })
+
+	return w.index.addSyscallSet(syscalls)
+}
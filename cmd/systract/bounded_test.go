@@ -0,0 +1,195 @@
+package systract
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestAnalyse_BoundedSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gosystract-bounded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	assertThat := func(assumption string, source SourceReader) {
+		should := should.New(t)
+		expected, err := Analyse(source)
+		should.NotError(err, assumption)
+
+		actual, err := Analyse(NewBoundedSource(source, dir))
+
+		should.NotError(err, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should find the same syscalls as in memory", NewDumpReader("../../test/go1.21-syscalls.dump"))
+	assertThat("should resolve syscall numbers passed to wrappers", NewDumpReader("../../test/param-wrappers.dump"))
+	assertThat("should link function values", NewDumpReader("../../test/function-values.dump"))
+	assertThat("should link methods of types converted into interfaces", NewDumpReader("../../test/interface-calls.dump"))
+	assertThat("should report unresolved syscall sites and calls", NewDumpReader("../../test/unresolved.dump"))
+	assertThat("should keep the last definition of duplicated symbols", NewDumpReader("../../test/duplicated-symbols.dump"))
+	assertThat("should handle other architectures", NewDumpReader("../../test/arm64-single-syscall.dump"))
+	assertThat("should handle executables", NewELFReader("../../test/simple-app"))
+	assertThat("should walk chains of generated symbols", &generatedSource{reachable: 1000, unreachable: 1000})
+	assertThat("should walk trees of generated symbols", &generatedSource{reachable: 1000, branches: 4})
+
+	files, err := ioutil.ReadDir(dir)
+	should.New(t).NotError(err, "should list temporary files")
+	should.New(t).BeEqual(0, len(files), "should not leave symbol indexes behind")
+}
+
+func TestAnalyse_BoundedSource_Errors(t *testing.T) {
+	should := should.New(t)
+
+	_, err := Analyse(NewBoundedSource(NewDumpReader("../../test/single-syscall.dump"), "/non-existent"))
+
+	should.Error(err, "should fail when the index cannot be created")
+}
+
+func TestAnalyse_BoundedSource_PeakMemory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping peak memory measurements in short mode")
+	}
+	should := should.New(t)
+
+	small := peakHeap(t, NewBoundedSource(&generatedSource{reachable: 5000, branches: 4}, ""))
+	large := peakHeap(t, NewBoundedSource(&generatedSource{reachable: 50000, branches: 4}, ""))
+	inMemory := peakHeap(t, &generatedSource{reachable: 50000, branches: 4})
+
+	t.Logf("peak heap: bounded %d KB for 5000 symbols, %d KB for 50000 symbols, in memory %d KB for 50000 symbols",
+		small/1024, large/1024, inMemory/1024)
+	should.BeTrue(large < small*3/2, "should keep peak memory flat as the number of symbols grows")
+	should.BeTrue(large < inMemory/2, "should use less memory than keeping all symbols in memory")
+}
+
+// peakHeap returns the highest heap usage sampled while analysing the source.
+func peakHeap(t *testing.T, source SourceReader) uint64 {
+	runtime.GC()
+
+	var peak uint64
+	var stats runtime.MemStats
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			runtime.ReadMemStats(&stats)
+			if stats.HeapAlloc > peak {
+				peak = stats.HeapAlloc
+			}
+
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	result, err := Analyse(source)
+	close(done)
+	wg.Wait()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.SystemCalls) != 1 {
+		t.Fatalf("unexpected syscalls: %v", result.SystemCalls)
+	}
+
+	return peak
+}
+
+func TestParseDumpBounded_ReachableSymbols(t *testing.T) {
+	assertThat := func(assumption string, source *generatedSource) {
+		should := should.New(t)
+		reader, err := source.GetReader()
+		should.NotError(err, assumption)
+		defer reader.Close()
+		arch := archs["amd64"]
+
+		symbols, err := parseDumpBounded(reader, arch, getCallingConvention(arch, ""), "", "")
+
+		should.NotError(err, assumption)
+		should.BeEqual(source.reachable+1, len(symbols), assumption)
+	}
+
+	assertThat("should load all symbols reachable from main",
+		&generatedSource{reachable: 1000})
+	assertThat("should not load unreachable symbols, however many there are",
+		&generatedSource{reachable: 1000, unreachable: 20000})
+}
+
+// generatedSource streams a dump in which main.main calls a tree of the number of reachable
+// symbols given, the last of which issues a single syscall, followed by a chain of the number
+// of unreachable symbols given. Each reachable symbol calls the number of branches given,
+// one by default, which makes a chain.
+type generatedSource struct {
+	reachable   int
+	unreachable int
+	branches    int
+}
+
+func (g *generatedSource) GetReader() (io.ReadCloser, error) {
+	reader, writer := io.Pipe()
+	go func() {
+		fmt.Fprint(writer, "TEXT main.main(SB) /app/main.go\n"+
+			"  main.go:5\t\t0x401000\t\te800000000\t\tCALL github.com/acme/generated.(*T0).Run(SB)\t\t\n"+
+			"  main.go:6\t\t0x401005\t\tc3\t\t\tRET\t\t\t\n\n")
+
+		if err := writeGeneratedSymbols(writer, "T", g.reachable, g.branches, 0x1000000); err != nil {
+			return
+		}
+		if err := writeGeneratedSymbols(writer, "U", g.unreachable, 1, 0x8000000); err != nil {
+			return
+		}
+		writer.Close()
+	}()
+
+	return reader, nil
+}
+
+// writeGeneratedSymbols writes a tree of methods of the types prefixed as given, in which
+// symbol i calls symbols i*branches+1 to i*branches+branches, bar the last symbol which
+// issues exit_group.
+func writeGeneratedSymbols(writer io.Writer, prefix string, count, branches, address int) error {
+	if branches < 1 {
+		branches = 1
+	}
+
+	for i := 0; i < count; i++ {
+		addr := address + i*0x40
+		body := ""
+		for callee := i*branches + 1; callee <= i*branches+branches && callee < count; callee++ {
+			body += fmt.Sprintf("  generated.go:%d\t\t0x%x\t\te800000000\t\tCALL github.com/acme/generated.(*%s%d).Run(SB)\t\t\n",
+				i, addr, prefix, callee)
+			addr += 5
+		}
+		if i == count-1 {
+			body = fmt.Sprintf("  generated.go:%d\t\t0x%x\t\tb8e7000000\t\tMOVL $0xe7, AX\t\t\n"+
+				"  generated.go:%d\t\t0x%x\t\t0f05\t\t\tSYSCALL\t\t\t\n", i, addr, i, addr+5)
+			addr += 7
+		}
+
+		_, err := fmt.Fprintf(writer, "TEXT github.com/acme/generated.(*%s%d).Run(SB) /app/generated.go\n%s"+
+			"  generated.go:%d\t\t0x%x\t\tc3\t\t\tRET\t\t\t\n\n", prefix, i, body, i, addr)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
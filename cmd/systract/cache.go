@@ -0,0 +1,296 @@
+package systract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	cacheDirName   string = "gosystract"
+	cacheEntryExt  string = ".json"
+	versionDirSize int    = 16
+)
+
+// Cache stores analysis results on disk, keyed by the go build id of the executables
+// analysed, or the SHA-256 of their contents when not available. Results are tied to
+// the build of gosystract which produced them, so upgrading it invalidates them.
+type Cache struct {
+	dir     string
+	version string
+}
+
+// DefaultCacheDir returns the default directory of the cache, within the user cache directory.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.Wrap(err, "could not find user cache directory")
+	}
+
+	return filepath.Join(dir, cacheDirName), nil
+}
+
+// NewCache initialises a cache within dir, or DefaultCacheDir when empty.
+func NewCache(dir string) (*Cache, error) {
+	if dir == "" {
+		d, err := DefaultCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = d
+	}
+
+	return &Cache{dir: dir, version: getCacheVersion()}, nil
+}
+
+// Dir returns the directory of the cache.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// versionDir returns the directory holding the results of the running version.
+func (c *Cache) versionDir() string {
+	sum := sha256.Sum256([]byte(c.version))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])[:versionDirSize])
+}
+
+func (c *Cache) entryPath(key string) string {
+	return filepath.Join(c.versionDir(), key+cacheEntryExt)
+}
+
+// get returns the result stored for the key, refreshing its modification
+// time so that pruning by age removes the entries not used for longest.
+func (c *Cache) get(key string) (*Result, bool) {
+	path := c.entryPath(key)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var result Result
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, false
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
+	return &result, true
+}
+
+// put stores the result for the key, writing it into a temporary
+// file first so that concurrent readers never see partial entries.
+func (c *Cache) put(key string, result *Result) error {
+	dir := c.versionDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrap(err, "could not create cache directory")
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(dir, key+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "could not write cache entry")
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), c.entryPath(key))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return errors.Wrap(err, "could not write cache entry")
+	}
+
+	return nil
+}
+
+// Prune removes the results of other gosystract versions, those not used within
+// maxAge and, when the remaining ones take more than maxSize bytes, those used
+// least recently. A zero maxAge or maxSize disables the respective limit.
+// It returns the number of results removed.
+func (c *Cache) Prune(maxAge time.Duration, maxSize int64) (int, error) {
+	dirs, err := ioutil.ReadDir(c.dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "could not read cache directory")
+	}
+
+	removed := 0
+	current := c.versionDir()
+	for _, d := range dirs {
+		path := filepath.Join(c.dir, d.Name())
+		if !d.IsDir() || path == current {
+			continue
+		}
+
+		entries, _ := ioutil.ReadDir(path)
+		if err := os.RemoveAll(path); err != nil {
+			return removed, errors.Wrap(err, "could not remove cache entries")
+		}
+		removed += countEntries(entries)
+	}
+
+	entries, err := ioutil.ReadDir(current)
+	if os.IsNotExist(err) {
+		return removed, nil
+	}
+	if err != nil {
+		return removed, errors.Wrap(err, "could not read cache directory")
+	}
+
+	// least recently used first.
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime().Before(entries[j].ModTime())
+	})
+
+	var size int64
+	for _, e := range entries {
+		size += e.Size()
+	}
+
+	now := time.Now()
+	for _, e := range entries {
+		expired := maxAge > 0 && now.Sub(e.ModTime()) > maxAge
+		oversized := maxSize > 0 && size > maxSize
+		if !expired && !oversized {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(current, e.Name())); err != nil && !os.IsNotExist(err) {
+			return removed, errors.Wrap(err, "could not remove cache entry")
+		}
+		size -= e.Size()
+		removed += countEntries([]os.FileInfo{e})
+	}
+
+	return removed, nil
+}
+
+// countEntries returns the number of results amongst the files, ignoring temporary files.
+func countEntries(files []os.FileInfo) int {
+	n := 0
+	for _, f := range files {
+		if strings.HasSuffix(f.Name(), cacheEntryExt) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// CachedSource wraps a source so that its analysis results are stored into a cache,
+// and loaded from it whenever the same input is analysed again.
+type CachedSource struct {
+	sourceWrapper
+	cache *Cache
+}
+
+// NewCachedSource initialises a new CachedSource, storing results into the cache provided.
+func NewCachedSource(source SourceReader, cache *Cache) *CachedSource {
+	return &CachedSource{sourceWrapper: sourceWrapper{source}, cache: cache}
+}
+
+// analyse returns the result cached for the source, analysing it on a miss. Sources which
+// are not files are always analysed. Failing to store results does not fail the analysis,
+// as they are only cached to speed up subsequent ones.
+func (c *CachedSource) analyse() (*Result, error) {
+	key, err := cacheKey(c.source)
+	if err != nil {
+		return Analyse(c.source)
+	}
+
+	if result, found := c.cache.get(key); found {
+		return result, nil
+	}
+
+	result, err := Analyse(c.source)
+	if err != nil {
+		return nil, err
+	}
+	_ = c.cache.put(key, result)
+
+	return result, nil
+}
+
+// cacheKey returns the key results of the source are cached by, derived from the
+// identity of its file and all settings changing its results: how the file is read
+// and the syscall wrappers it is analysed with.
+func cacheKey(source SourceReader) (string, error) {
+	s, ok := source.(fileSource)
+	if !ok {
+		return "", errors.New("source is not a file")
+	}
+	path, err := s.file()
+	if err != nil {
+		return "", err
+	}
+
+	id, err := getFileID(path)
+	if err != nil {
+		return "", err
+	}
+
+	wrappers, err := getSyscallWrappers(source)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%T\n%s\n", unwrapSource(source), id)
+	for _, w := range append(registeredSyscallWrappers(), wrappers...) {
+		fmt.Fprintf(h, "%s:%d\n", w.Name, w.TrapArg)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// getFileID returns the go build id of executables, or the SHA-256 of the file contents.
+func getFileID(path string) (string, error) {
+	if id, err := getBuildID(path); err == nil && id != "" {
+		return "buildid:" + id, nil
+	}
+
+	/* #nosec path is pre-processed by sanitiseFileName */
+	f, err := os.Open(path)
+	if err != nil {
+		return "", errors.New("file does not exist or permission denied")
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.Wrap(err, "could not read file")
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// getCacheVersion identifies the running build of gosystract by the build id of its
+// executable, so that rebuilding it invalidates cached results.
+func getCacheVersion() string {
+	if path, err := os.Executable(); err == nil {
+		if id, err := getFileID(path); err == nil {
+			return id
+		}
+	}
+
+	return "unknown"
+}
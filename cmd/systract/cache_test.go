@@ -0,0 +1,154 @@
+package systract
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+// countingSource counts how many times the file it wraps is read.
+type countingSource struct {
+	*ELFReader
+	reads int
+}
+
+func (c *countingSource) GetReader() (io.ReadCloser, error) {
+	c.reads++
+	return c.ELFReader.GetReader()
+}
+
+func newTestCache(t *testing.T, version string) (*Cache, func()) {
+	dir, err := ioutil.TempDir("", "gosystract-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Cache{dir: dir, version: version}, func() { os.RemoveAll(dir) }
+}
+
+func TestAnalyse_CachedSource(t *testing.T) {
+	should := should.New(t)
+	cache, cleanup := newTestCache(t, "v1")
+	defer cleanup()
+
+	source := &countingSource{ELFReader: NewELFReader("../../test/simple-app")}
+	expected, err := Analyse(source)
+	should.NotError(err, "should analyse source")
+
+	miss, err := Analyse(NewCachedSource(source, cache))
+	should.NotError(err, "should analyse source on cache misses")
+	should.BeEqual(expected, miss, "should return the result of the source on cache misses")
+	should.BeEqual(2, source.reads, "should read the source on cache misses")
+
+	hit, err := Extract(NewCachedSource(source, cache))
+	should.NotError(err, "should return results on cache hits")
+	should.BeEqual(expected.SystemCalls, hit, "should return the cached result on cache hits")
+	should.BeEqual(2, source.reads, "should not read the source on cache hits")
+
+	cache.version = "v2"
+	_, err = Analyse(NewCachedSource(source, cache))
+	should.NotError(err, "should analyse source once the version changes")
+	should.BeEqual(3, source.reads, "should invalidate results of other versions")
+}
+
+func TestAnalyse_CachedSource_Errors(t *testing.T) {
+	should := should.New(t)
+	cache, cleanup := newTestCache(t, "v1")
+	defer cleanup()
+
+	_, err := Analyse(NewCachedSource(NewELFReader("../../test/non-existent"), cache))
+	should.Error(err, "should error when the source cannot be read")
+
+	cache.dir = "../../test/single-syscall.dump/cache"
+	result, err := Analyse(NewCachedSource(NewDumpReader("../../test/single-syscall.dump"), cache))
+	should.NotError(err, "should not error when results cannot be cached")
+	should.BeEqual(1, len(result.SystemCalls), "should return the results which cannot be cached")
+}
+
+func TestCacheKey(t *testing.T) {
+	should := should.New(t)
+
+	elf, err := cacheKey(NewELFReader("../../test/simple-app"))
+	should.NotError(err, "should key executables")
+	exe, err := cacheKey(NewExeReader("../../test/simple-app"))
+	should.NotError(err, "should key executables handled by go tool objdump")
+	bounded, err := cacheKey(NewBoundedSource(NewELFReader("../../test/simple-app"), ""))
+	should.NotError(err, "should key wrapped sources")
+	dump, err := cacheKey(NewDumpReader("../../test/single-syscall.dump"))
+	should.NotError(err, "should key dump files by their checksum")
+	_, err = cacheKey(&generatedSource{})
+	should.Error(err, "should not key sources which are not files")
+
+	should.BeFalse(elf == exe, "should key by how executables are read")
+	should.BeEqual(elf, bounded, "should key wrapped sources as the source wrapped")
+	should.BeFalse(elf == dump, "should key by file")
+
+	wrappers := []SyscallWrapper{{Name: "example.com/cache/key.syscall", TrapArg: 0}}
+	withWrapper, _ := cacheKey(NewWrapperSource(NewELFReader("../../test/simple-app"), wrappers))
+	should.BeFalse(elf == withWrapper, "should key by the syscall wrappers provided")
+
+	should.NotError(RegisterSyscallWrapper("example.com/cache/key.syscall", 0), "should register wrapper")
+	defer unregisterSyscallWrapper("example.com/cache/key.syscall")
+	registered, _ := cacheKey(NewELFReader("../../test/simple-app"))
+	should.BeFalse(elf == registered, "should key by the syscall wrappers registered")
+}
+
+func TestCache_Prune(t *testing.T) {
+	should := should.New(t)
+	cache, cleanup := newTestCache(t, "v1")
+	defer cleanup()
+
+	old := &Cache{dir: cache.dir, version: "v0"}
+	should.NotError(old.put("stale", &Result{}), "should store results")
+
+	now := time.Now()
+	for i, key := range []string{"a", "b", "c", "d"} {
+		should.NotError(cache.put(key, &Result{Arch: "amd64"}), "should store results")
+		modTime := now.Add(time.Duration(i-4) * time.Hour)
+		should.NotError(os.Chtimes(cache.entryPath(key), modTime, modTime), "should set modification time")
+	}
+	info, err := os.Stat(cache.entryPath("a"))
+	should.NotError(err, "should stat results")
+
+	removed, err := cache.Prune(0, 0)
+	should.NotError(err, "should prune")
+	should.BeEqual(1, removed, "should remove results of other versions")
+
+	removed, err = cache.Prune(150*time.Minute, 0)
+	should.NotError(err, "should prune")
+	should.BeEqual(2, removed, "should remove results older than max age")
+
+	_, found := cache.get("c")
+	should.BeTrue(found, "should keep results within max age")
+
+	removed, err = cache.Prune(0, info.Size())
+	should.NotError(err, "should prune")
+	should.BeEqual(1, removed, "should remove least recently used results beyond max size")
+
+	_, found = cache.get("c")
+	should.BeTrue(found, "should keep most recently used results")
+	_, found = cache.get("d")
+	should.BeFalse(found, "should remove least recently used results")
+
+	files, err := ioutil.ReadDir(cache.dir)
+	should.NotError(err, "should list cache")
+	should.BeEqual(1, len(files), "should only keep the directory of the current version")
+	should.BeEqual(filepath.Base(cache.versionDir()), files[0].Name(), "should keep the directory of the current version")
+}
+
+func TestCache_Prune_Empty(t *testing.T) {
+	should := should.New(t)
+	cache, cleanup := newTestCache(t, "v1")
+	defer cleanup()
+	cache.dir = filepath.Join(cache.dir, "missing")
+
+	removed, err := cache.Prune(time.Hour, 1)
+
+	should.NotError(err, "should not error when the cache does not exist")
+	should.BeEqual(0, removed, "should remove nothing")
+}
@@ -0,0 +1,157 @@
+package systract
+
+import "sort"
+
+// callGraph memoises the system calls reachable from each symbol, so that symbols
+// shared across entry points are only walked once. Symbols calling each other are
+// grouped into strongly connected components, which share a single syscall set derived
+// bottom-up from their own syscalls and those of the components they call.
+type callGraph struct {
+	symbols map[string]symbolDefinition
+
+	index   map[string]int
+	lowLink map[string]int
+	onStack map[string]bool
+	stack   []string
+
+	syscalls map[string][]uint16
+}
+
+func newCallGraph(symbols map[string]symbolDefinition) *callGraph {
+	return &callGraph{
+		symbols:  symbols,
+		index:    make(map[string]int),
+		lowLink:  make(map[string]int),
+		onStack:  make(map[string]bool),
+		syscalls: make(map[string][]uint16),
+	}
+}
+
+// reachableSyscalls returns the sorted ids of all system calls reachable from the symbol.
+// The slice returned is shared with other symbols and must not be modified.
+func (g *callGraph) reachableSyscalls(symbol string) []uint16 {
+	if _, found := g.symbols[symbol]; !found {
+		return nil
+	}
+
+	if _, visited := g.index[symbol]; !visited {
+		g.visit(symbol)
+	}
+
+	return g.syscalls[symbol]
+}
+
+// reachableSymbols returns the names of all symbols reachable from the entry points,
+// sorted by name. These are the symbols walked to find their syscalls, so the graph
+// must not have been queried for symbols other than the entry points.
+func (g *callGraph) reachableSymbols(entryPoints []string) []string {
+	for _, symbol := range entryPoints {
+		g.reachableSyscalls(symbol)
+	}
+
+	names := make([]string, 0, len(g.index))
+	for name := range g.index {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// visit walks the symbol following Tarjan's algorithm, deriving the syscall set of
+// each component once all components it calls have been completed.
+func (g *callGraph) visit(symbol string) {
+	g.index[symbol] = len(g.index)
+	g.lowLink[symbol] = g.index[symbol]
+	g.stack = append(g.stack, symbol)
+	g.onStack[symbol] = true
+
+	for _, callee := range g.symbols[symbol].subCalls {
+		if _, found := g.symbols[callee]; !found {
+			continue
+		}
+
+		if _, visited := g.index[callee]; !visited {
+			g.visit(callee)
+			if g.lowLink[callee] < g.lowLink[symbol] {
+				g.lowLink[symbol] = g.lowLink[callee]
+			}
+		} else if g.onStack[callee] && g.index[callee] < g.lowLink[symbol] {
+			g.lowLink[symbol] = g.index[callee]
+		}
+	}
+
+	if g.lowLink[symbol] != g.index[symbol] {
+		return
+	}
+
+	// symbol is the root of its component, whose members sit above it on the stack.
+	var members []string
+	for {
+		member := g.stack[len(g.stack)-1]
+		g.stack = g.stack[:len(g.stack)-1]
+		g.onStack[member] = false
+		members = append(members, member)
+
+		if member == symbol {
+			break
+		}
+	}
+
+	syscalls := g.componentSyscalls(members)
+	for _, member := range members {
+		g.syscalls[member] = syscalls
+	}
+}
+
+// componentSyscalls merges the syscalls issued by the members of a component with the
+// sets of the components they call, which have all been completed by then. The set of
+// a single callee is reused as is when the component adds nothing to it.
+func (g *callGraph) componentSyscalls(members []string) []uint16 {
+	unique := make(map[uint16]bool)
+	var callees [][]uint16
+
+	for _, member := range members {
+		s := g.symbols[member]
+		for _, id := range s.syscallIDs {
+			unique[id] = true
+		}
+
+		for _, callee := range s.subCalls {
+			if ids := g.syscalls[callee]; len(ids) > 0 {
+				callees = append(callees, ids)
+			}
+		}
+	}
+
+	if len(unique) == 0 && sameSyscallSets(callees) {
+		if len(callees) == 0 {
+			return nil
+		}
+		return callees[0]
+	}
+
+	for _, ids := range callees {
+		for _, id := range ids {
+			unique[id] = true
+		}
+	}
+
+	syscalls := make([]uint16, 0, len(unique))
+	for id := range unique {
+		syscalls = append(syscalls, id)
+	}
+	sort.Slice(syscalls, func(i, j int) bool { return syscalls[i] < syscalls[j] })
+
+	return syscalls
+}
+
+// sameSyscallSets returns whether all sets provided are the same memoised slice.
+func sameSyscallSets(sets [][]uint16) bool {
+	for _, ids := range sets {
+		if &ids[0] != &sets[0][0] {
+			return false
+		}
+	}
+	return true
+}
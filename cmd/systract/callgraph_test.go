@@ -0,0 +1,231 @@
+package systract
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestCallGraph_ReachableSyscalls(t *testing.T) {
+	symbols := map[string]symbolDefinition{
+		"main.main":  {name: "main.main", subCalls: []string{"main.a", "main.b", "fmt.Println"}},
+		"main.a":     {name: "main.a", syscallIDs: []uint16{1}, subCalls: []string{"main.b"}},
+		"main.b":     {name: "main.b", subCalls: []string{"main.c"}},
+		"main.c":     {name: "main.c", syscallIDs: []uint16{3}, subCalls: []string{"main.b", "main.d"}},
+		"main.d":     {name: "main.d", syscallIDs: []uint16{0}},
+		"main.e":     {name: "main.e", subCalls: []string{"main.d"}},
+		"main.empty": {name: "main.empty"},
+	}
+
+	assertThat := func(assumption, symbol string, expected []uint16) {
+		should := should.New(t)
+		graph := newCallGraph(symbols)
+
+		actual := graph.reachableSyscalls(symbol)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should merge syscalls of all callees", "main.main", []uint16{0, 1, 3})
+	assertThat("should share syscalls within mutually recursive symbols", "main.b", []uint16{0, 3})
+	assertThat("should share syscalls within mutually recursive symbols", "main.c", []uint16{0, 3})
+	assertThat("should reuse the syscalls of a single callee", "main.e", []uint16{0})
+	assertThat("should return nil for symbols without syscalls", "main.empty", nil)
+	assertThat("should return nil for unknown symbols", "fmt.Println", nil)
+}
+
+func TestCallGraph_Memoised(t *testing.T) {
+	should := should.New(t)
+	symbols := map[string]symbolDefinition{
+		"main.a": {name: "main.a", subCalls: []string{"main.b"}},
+		"main.b": {name: "main.b", subCalls: []string{"main.a", "main.c"}},
+		"main.c": {name: "main.c", syscallIDs: []uint16{2, 1}},
+	}
+	graph := newCallGraph(symbols)
+
+	a := graph.reachableSyscalls("main.a")
+	b := graph.reachableSyscalls("main.b")
+	c := graph.reachableSyscalls("main.c")
+
+	should.BeEqual([]uint16{1, 2}, c, "should sort syscall ids")
+	should.BeTrue(&a[0] == &b[0], "should share a single set within components")
+	should.BeTrue(&a[0] == &c[0], "should reuse the set of the single component called")
+}
+
+func TestCallGraph_ReachableSymbols(t *testing.T) {
+	should := should.New(t)
+	symbols := map[string]symbolDefinition{
+		"main.main": {name: "main.main", subCalls: []string{"main.b", "main.a", "fmt.Println"}},
+		"main.a":    {name: "main.a", subCalls: []string{"main.b"}},
+		"main.b":    {name: "main.b", syscallIDs: []uint16{1}, subCalls: []string{"main.a"}},
+		"main.c":    {name: "main.c", syscallIDs: []uint16{3}},
+	}
+	graph := newCallGraph(symbols)
+
+	actual := graph.reachableSymbols([]string{"main.main", "main.init.0"})
+
+	should.BeEqual([]string{"main.a", "main.b", "main.main"}, actual,
+		"should return the known symbols reachable from the entry points, sorted by name")
+}
+
+func TestExtractSyscalls_Deterministic(t *testing.T) {
+	should := should.New(t)
+	arch, symbols, entryPoints := parseEntryPoints(t, NewDumpReader("../../test/systrac.dump"))
+
+	expected := extractSyscalls(newCallGraph(symbols), arch, entryPoints)
+	for i := 0; i < 5; i++ {
+		should.BeEqual(expected, extractSyscalls(newCallGraph(symbols), arch, entryPoints),
+			"should return syscalls in the same order")
+	}
+}
+
+func parseEntryPoints(tb testing.TB, source SourceReader) (*archSpec, map[string]symbolDefinition, []string) {
+	arch, symbols, err := parseSource(source)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	roots := findRuntimeRoots(symbols, arch.name, getGoVersion(source))
+	return arch, symbols, getEntryPoints(symbols, roots)
+}
+
+func TestExtractSyscalls_SameAsBaseline(t *testing.T) {
+	assertThat := func(assumption string, arch *archSpec, symbols map[string]symbolDefinition, entryPoints []string) {
+		should := should.New(t)
+
+		expected := walkSyscalls(symbols, arch, entryPoints)
+		actual := extractSyscalls(newCallGraph(symbols), arch, entryPoints)
+
+		should.HaveSameItems(keys(expected), keys(actual), assumption)
+	}
+
+	arch, symbols, entryPoints := parseEntryPoints(t, NewELFReader("../../test/simple-app"))
+	assertThat("should find the syscalls of the per entry point walk for simple-app", arch, symbols, entryPoints)
+
+	symbols, entryPoints = generateCallGraph(1000)
+	assertThat("should find the syscalls of the per entry point walk for generated graphs", archs["amd64"], symbols, entryPoints)
+}
+
+func BenchmarkExtractSyscalls_Dump(b *testing.B) {
+	arch, symbols, entryPoints := parseEntryPoints(b, NewDumpReader("../../test/systrac.dump"))
+	benchmarkExtractSyscalls(b, arch, symbols, entryPoints)
+}
+
+func BenchmarkExtractSyscalls_ELF(b *testing.B) {
+	arch, symbols, entryPoints := parseEntryPoints(b, NewELFReader("../../test/simple-app"))
+	benchmarkExtractSyscalls(b, arch, symbols, entryPoints)
+}
+
+func BenchmarkExtractSyscalls_ELFBaseline(b *testing.B) {
+	arch, symbols, entryPoints := parseEntryPoints(b, NewELFReader("../../test/simple-app"))
+	benchmarkWalkSyscalls(b, arch, symbols, entryPoints)
+}
+
+func BenchmarkExtractSyscalls_Generated(b *testing.B) {
+	symbols, entryPoints := generateCallGraph(20000)
+	benchmarkExtractSyscalls(b, archs["amd64"], symbols, entryPoints)
+}
+
+func BenchmarkExtractSyscalls_GeneratedBaseline(b *testing.B) {
+	symbols, entryPoints := generateCallGraph(20000)
+	benchmarkWalkSyscalls(b, archs["amd64"], symbols, entryPoints)
+}
+
+func benchmarkExtractSyscalls(b *testing.B, arch *archSpec, symbols map[string]symbolDefinition, entryPoints []string) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		extractSyscalls(newCallGraph(symbols), arch, entryPoints)
+	}
+}
+
+func benchmarkWalkSyscalls(b *testing.B, arch *archSpec, symbols map[string]symbolDefinition, entryPoints []string) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		walkSyscalls(symbols, arch, entryPoints)
+	}
+}
+
+// walkSyscalls is the walk extractSyscalls replaced, which visits all symbols reachable
+// from each entry point on its own goroutine, sharing nothing across entry points.
+// It is kept as the baseline of the call graph benchmarks.
+func walkSyscalls(symbols map[string]symbolDefinition, arch *archSpec, entryPoints []string) []SystemCall {
+	syscallID := make(chan uint16)
+
+	var wg sync.WaitGroup
+	wg.Add(len(entryPoints))
+	for _, symbol := range entryPoints {
+		go func(s string) {
+			defer wg.Done()
+			processed := make(map[string]bool)
+			var walk func(symbol string)
+			walk = func(symbol string) {
+				if processed[symbol] {
+					return
+				}
+				processed[symbol] = true
+				if s, found := symbols[symbol]; found {
+					for _, id := range s.syscallIDs {
+						syscallID <- id
+					}
+					for _, name := range s.subCalls {
+						walk(name)
+					}
+				}
+			}
+			walk(s)
+		}(symbol)
+	}
+
+	go func() {
+		wg.Wait()
+		close(syscallID)
+	}()
+
+	syscalls := make([]SystemCall, 0)
+	unique := make(map[uint16]bool)
+	for id := range syscallID {
+		if !unique[id] {
+			unique[id] = true
+			syscalls = append(syscalls, SystemCall{ID: id, Name: arch.systemCalls[id]})
+		}
+	}
+
+	return syscalls
+}
+
+// generateCallGraph returns n symbols shaped as go executables are, where most entry
+// points reach a large runtime shared amongst them, with recursion and syscalls spread
+// across symbols. The graph is the same for the same n.
+func generateCallGraph(n int) (map[string]symbolDefinition, []string) {
+	r := rand.New(rand.NewSource(int64(n)))
+	symbols := make(map[string]symbolDefinition, n)
+	name := func(i int) string { return fmt.Sprintf("pkg%d.f%d", i%100, i) }
+
+	for i := 0; i < n; i++ {
+		s := symbolDefinition{name: name(i), subCalls: make([]string, 0, 4), syscallIDs: make([]uint16, 0)}
+		for c := 0; c < 4; c++ {
+			// calls go mostly to later symbols, the runtime, with some calling back.
+			callee := i + 1 + r.Intn(n/10+1)
+			if r.Intn(20) == 0 {
+				callee = r.Intn(i + 1)
+			}
+			if callee < n {
+				s.subCalls = append(s.subCalls, name(callee))
+			}
+		}
+		if r.Intn(10) == 0 {
+			s.syscallIDs = append(s.syscallIDs, uint16(r.Intn(300)))
+		}
+		symbols[s.name] = s
+	}
+
+	entryPoints := make([]string, 0, 50)
+	for i := 0; i < 50; i++ {
+		entryPoints = append(entryPoints, name(r.Intn(n/10)))
+	}
+
+	return symbols, entryPoints
+}
@@ -0,0 +1,279 @@
+package systract
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+)
+
+var errCorruptRecord = errors.New("corrupt symbol record")
+
+// recordEncoder appends values to a buffer using variable-length integers,
+// strings being prefixed by their length.
+type recordEncoder struct {
+	buf     []byte
+	scratch [binary.MaxVarintLen64]byte
+}
+
+func (e *recordEncoder) uint(v uint64) {
+	n := binary.PutUvarint(e.scratch[:], v)
+	e.buf = append(e.buf, e.scratch[:n]...)
+}
+
+func (e *recordEncoder) int(v int64) {
+	n := binary.PutVarint(e.scratch[:], v)
+	e.buf = append(e.buf, e.scratch[:n]...)
+}
+
+func (e *recordEncoder) string(s string) {
+	e.uint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *recordEncoder) strings(values []string) {
+	e.uint(uint64(len(values)))
+	for _, s := range values {
+		e.string(s)
+	}
+}
+
+func (e *recordEncoder) location(l Location) {
+	e.string(l.Symbol)
+	e.string(l.File)
+	e.int(int64(l.Line))
+	e.uint(l.Address)
+}
+
+// recordDecoder reads values written by recordEncoder, keeping the first error found,
+// after which all values read are zero.
+type recordDecoder struct {
+	buf []byte
+	err error
+}
+
+func (d *recordDecoder) uint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.err = errCorruptRecord
+		return 0
+	}
+	d.buf = d.buf[n:]
+
+	return v
+}
+
+func (d *recordDecoder) int() int64 {
+	if d.err != nil {
+		return 0
+	}
+
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.err = errCorruptRecord
+		return 0
+	}
+	d.buf = d.buf[n:]
+
+	return v
+}
+
+// count reads the length of a list, which cannot exceed the bytes left.
+func (d *recordDecoder) count() int {
+	n := d.uint()
+	if n > uint64(len(d.buf)) {
+		d.err = errCorruptRecord
+		return 0
+	}
+
+	return int(n)
+}
+
+func (d *recordDecoder) string() string {
+	n := d.count()
+	if d.err != nil {
+		return ""
+	}
+
+	s := string(d.buf[:n])
+	d.buf = d.buf[n:]
+
+	return s
+}
+
+func (d *recordDecoder) strings() []string {
+	n := d.count()
+	values := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		values = append(values, d.string())
+	}
+
+	return values
+}
+
+func (d *recordDecoder) location() Location {
+	return Location{
+		Symbol:  d.string(),
+		File:    d.string(),
+		Line:    int(d.int()),
+		Address: d.uint(),
+	}
+}
+
+// encodeSymbol appends the symbol definition to the encoder, bar its name. Maps are
+// written sorted by key, so that the same definition is always encoded alike.
+func encodeSymbol(e *recordEncoder, s symbolDefinition) {
+	e.uint(uint64(len(s.syscallIDs)))
+	for _, id := range s.syscallIDs {
+		e.uint(uint64(id))
+	}
+	e.strings(s.subCalls)
+	e.strings(s.types)
+	e.strings(s.references)
+
+	e.uint(uint64(len(s.confidence)))
+	for _, id := range sortedConfidenceIDs(s.confidence) {
+		e.uint(uint64(id))
+		e.string(string(s.confidence[id]))
+	}
+
+	e.uint(uint64(len(s.sites)))
+	for _, id := range sortedSiteIDs(s.sites) {
+		e.uint(uint64(id))
+		e.uint(uint64(len(s.sites[id])))
+		for _, l := range s.sites[id] {
+			e.location(l)
+		}
+	}
+
+	e.uint(uint64(len(s.params)))
+	for _, pos := range s.params {
+		e.int(int64(pos))
+	}
+
+	e.uint(uint64(len(s.calls)))
+	for _, call := range s.calls {
+		e.string(call.target)
+		e.uint(uint64(len(call.args)))
+		positions := make([]int, 0, len(call.args))
+		for pos := range call.args {
+			positions = append(positions, pos)
+		}
+		sort.Ints(positions)
+		for _, pos := range positions {
+			e.int(int64(pos))
+			e.uint(uint64(len(call.args[pos])))
+			for _, v := range call.args[pos] {
+				e.int(v)
+			}
+		}
+		e.int(int64(call.index))
+		e.location(call.location)
+	}
+
+	e.uint(uint64(len(s.unresolved)))
+	for _, u := range s.unresolved {
+		e.string(string(u.Reason))
+		e.location(u.Location)
+		e.uint(uint64(u.ID))
+		e.string(u.Target)
+	}
+}
+
+// decodeSymbol reads a symbol definition written by encodeSymbol.
+func decodeSymbol(d *recordDecoder) (symbolDefinition, error) {
+	var s symbolDefinition
+
+	s.syscallIDs = make([]uint16, d.count())
+	for i := range s.syscallIDs {
+		s.syscallIDs[i] = uint16(d.uint())
+	}
+	s.subCalls = d.strings()
+	s.types = d.strings()
+	s.references = d.strings()
+
+	if n := d.count(); n > 0 {
+		s.confidence = make(map[uint16]Confidence, n)
+		for i := 0; i < n; i++ {
+			id := uint16(d.uint())
+			s.confidence[id] = Confidence(d.string())
+		}
+	}
+
+	if n := d.count(); n > 0 {
+		s.sites = make(map[uint16][]Location, n)
+		for i := 0; i < n; i++ {
+			id := uint16(d.uint())
+			locations := make([]Location, d.count())
+			for j := range locations {
+				locations[j] = d.location()
+			}
+			s.sites[id] = locations
+		}
+	}
+
+	if n := d.count(); n > 0 {
+		s.params = make([]int, n)
+		for i := range s.params {
+			s.params[i] = int(d.int())
+		}
+	}
+
+	if n := d.count(); n > 0 {
+		s.calls = make([]callArgs, n)
+		for i := range s.calls {
+			call := callArgs{target: d.string()}
+			for j, args := 0, d.count(); j < args; j++ {
+				if call.args == nil {
+					call.args = make(map[int][]int64, args)
+				}
+				pos := int(d.int())
+				values := make([]int64, d.count())
+				for k := range values {
+					values[k] = d.int()
+				}
+				call.args[pos] = values
+			}
+			call.index = int(d.int())
+			call.location = d.location()
+			s.calls[i] = call
+		}
+	}
+
+	if n := d.count(); n > 0 {
+		s.unresolved = make([]Unresolved, n)
+		for i := range s.unresolved {
+			s.unresolved[i] = Unresolved{
+				Reason:   UnresolvedReason(d.string()),
+				Location: d.location(),
+				ID:       uint16(d.uint()),
+				Target:   d.string(),
+			}
+		}
+	}
+
+	return s, d.err
+}
+
+func sortedConfidenceIDs(m map[uint16]Confidence) []uint16 {
+	ids := make([]uint16, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids
+}
+
+func sortedSiteIDs(m map[uint16][]Location) []uint16 {
+	ids := make([]uint16, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids
+}
@@ -0,0 +1,59 @@
+package systract
+
+import (
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestEncodeSymbol(t *testing.T) {
+	assertThat := func(assumption string, symbol symbolDefinition) {
+		should := should.New(t)
+		var e recordEncoder
+		encodeSymbol(&e, symbol)
+
+		actual, err := decodeSymbol(&recordDecoder{buf: e.buf})
+
+		should.NotError(err, assumption)
+		should.BeEqual(symbol, actual, assumption)
+	}
+
+	location := Location{Symbol: "main.main", File: "/app/main.go", Line: 12, Address: 0x401000}
+	assertThat("should round-trip empty symbols", symbolDefinition{
+		syscallIDs: []uint16{}, subCalls: []string{}, types: []string{}, references: []string{},
+	})
+	assertThat("should round-trip all fields", symbolDefinition{
+		syscallIDs: []uint16{0, 231},
+		subCalls:   []string{"main.f", "syscall.Syscall"},
+		types:      []string{"*os.File"},
+		references: []string{"main.main.func1"},
+		confidence: map[uint16]Confidence{0: ConfidenceHigh, 231: ConfidenceMedium},
+		sites:      map[uint16][]Location{0: {location}, 231: {location, {Symbol: "main.main", Line: -1}}},
+		params:     []int{0, 2},
+		calls: []callArgs{
+			{target: "syscall.Syscall", args: map[int][]int64{0: {1, paramMarker}}, index: 3, location: location},
+			{target: "main.f", index: 4},
+		},
+		unresolved: []Unresolved{
+			{Reason: UnresolvedDynamic, Location: location, Target: "syscall.Syscall"},
+			{Reason: UnresolvedUnknownID, Location: location, ID: 999},
+		},
+	})
+}
+
+func TestDecodeSymbol_Errors(t *testing.T) {
+	assertThat := func(assumption string, buf []byte) {
+		should := should.New(t)
+
+		_, err := decodeSymbol(&recordDecoder{buf: buf})
+
+		should.Error(err, assumption)
+	}
+
+	var e recordEncoder
+	encodeSymbol(&e, symbolDefinition{subCalls: []string{"main.f"}})
+
+	assertThat("should fail on empty records", []byte{})
+	assertThat("should fail on truncated records", e.buf[:len(e.buf)-3])
+	assertThat("should fail on lengths beyond the record", []byte{0xff, 0x01})
+}
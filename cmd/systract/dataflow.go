@@ -0,0 +1,717 @@
+package systract
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Confidence represents how certain the number of a system call is.
+type Confidence string
+
+const (
+	// ConfidenceHigh is used when the same constant reaches the syscall site on all paths.
+	ConfidenceHigh Confidence = "high"
+	// ConfidenceMedium is used when different constants reach the syscall site on different paths.
+	ConfidenceMedium Confidence = "medium"
+)
+
+const (
+	// maxValues bounds the number of constants tracked per location, beyond which it is unknown.
+	maxValues int = 4
+	// maxIterations bounds the iterations over the basic blocks of a function.
+	maxIterations int = 10000
+	// maxForwardedArgs bounds the positions of the call arguments tracked for propagation into wrappers.
+	maxForwardedArgs int = 10
+	// paramMarker is a multiple of the values representing the parameters of the function,
+	// which are far apart from each other so they are not confused once offset by additions.
+	paramMarker int64 = -1 << 40
+	// fieldSeparators are the characters separating the fields of go tool objdump lines.
+	fieldSeparators string = " \t\r\n\f"
+	// frameLocation holds the size of the stack frame of the function, when known.
+	frameLocation string = "frame"
+)
+
+// rank orders confidence levels, so the highest can be kept.
+func (c Confidence) rank() int {
+	switch c {
+	case ConfidenceHigh:
+		return 3
+	case ConfidenceMedium:
+		return 2
+	}
+
+	return 0
+}
+
+type instruction struct {
+	addr uint64
+	text string
+	op   string
+	args []string
+	// target is the function called by direct calls, either a symbol or an address,
+	// or by tail calls of object files, which are only known by their relocation.
+	target string
+}
+
+// syscallSite represents the syscall numbers resolved for an instruction issuing a system call.
+type syscallSite struct {
+	ids        []uint16
+	confidence Confidence
+	// params contains the positions of the parameters of the function used as the syscall number.
+	params []int
+	// unknownIDs contains the constants used as the syscall number which are not in the syscall table.
+	unknownIDs []uint16
+}
+
+// callArgs contains the syscall numbers, or parameters of the caller, passed
+// by position to a call, so they can be propagated into parameterised wrappers.
+type callArgs struct {
+	target string
+	args   map[int][]int64
+	// index is the position of the line of the call, whose location is set once parsed.
+	index    int
+	location Location
+}
+
+// flowState maps registers and stack slots to the constants they may hold.
+// Locations not in the map hold unknown values.
+type flowState map[string][]int64
+
+type basicBlock struct {
+	start, end int
+	succs      []int
+}
+
+// parseInstructions parses the instructions of a symbol, alongside the targets of their calls.
+// Lines which are not instructions are left as zero values, so positions are kept.
+func parseInstructions(lines []string, arch *archSpec) []instruction {
+	insts := make([]instruction, len(lines))
+	for i, line := range lines {
+		if inst, ok := parseInstruction(line); ok {
+			if inst.target == "" {
+				inst.target = arch.callTarget(inst)
+			}
+			insts[i] = inst
+		}
+	}
+
+	return insts
+}
+
+// parseInstruction returns the instruction of a go tool objdump line, made of its
+// source location, address, encoding and text, e.g. "  cpu.go:141\t0x401000\t0f05\tSYSCALL",
+// followed by the relocations of object files, e.g. "[1:5]R_CALL:runtime.morestack_noctxt".
+// Lines are split by hand, as this is called for every instruction of the dump.
+func parseInstruction(line string) (instruction, bool) {
+	_, rest := nextField(line)
+	address, rest := nextField(rest)
+	encoding, rest := nextField(rest)
+	if len(address) < 3 || !strings.HasPrefix(address, "0x") || !isHex(address[2:]) || !isHex(encoding) {
+		return instruction{}, false
+	}
+
+	relocations := ""
+	if i := strings.IndexByte(rest, '\t'); i >= 0 {
+		rest, relocations = rest[:i], rest[i+1:]
+	}
+	if rest == "" {
+		return instruction{}, false
+	}
+
+	addr, err := strconv.ParseUint(address, 0, 64)
+	if err != nil {
+		return instruction{}, false
+	}
+
+	text := strings.TrimSpace(rest)
+	inst := instruction{addr: addr, text: text, op: text, target: relocationTarget(relocations)}
+	if i := strings.IndexByte(text, ' '); i >= 0 {
+		inst.op = text[:i]
+		for _, arg := range strings.Split(text[i+1:], ",") {
+			inst.args = append(inst.args, strings.TrimSpace(arg))
+		}
+	}
+
+	return inst, true
+}
+
+// relocationTarget returns the function called through a call relocation,
+// e.g. runtime.morestack_noctxt for [1:5]R_CALL:runtime.morestack_noctxt.
+func relocationTarget(relocations string) string {
+	i := strings.Index(relocations, "R_CALL")
+	if i < 0 {
+		return ""
+	}
+
+	relocation := relocations[i:]
+	if j := strings.IndexByte(relocation, '\t'); j >= 0 {
+		relocation = relocation[:j]
+	}
+	j := strings.IndexByte(relocation, ':')
+	if j < 0 {
+		return ""
+	}
+
+	return trimOffset(relocation[j+1:])
+}
+
+// trimOffset removes the offset from a symbol reference, e.g. runtime.duffzero+0x10.
+func trimOffset(name string) string {
+	i := strings.LastIndexByte(name, '+')
+	if i <= 0 {
+		return name
+	}
+
+	offset := name[i+1:]
+	if strings.HasPrefix(offset, "0x") {
+		offset = offset[2:]
+	}
+	if !isHex(offset) {
+		return name
+	}
+
+	return name[:i]
+}
+
+// nextField returns the first whitespace separated field of the line, followed by
+// the remainder of the line without its leading whitespace.
+func nextField(line string) (string, string) {
+	line = strings.TrimLeft(line, fieldSeparators)
+	i := strings.IndexAny(line, fieldSeparators)
+	if i < 0 {
+		return line, ""
+	}
+
+	return line[:i], strings.TrimLeft(line[i:], fieldSeparators)
+}
+
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// resolveSyscallSites does a data-flow analysis over the basic blocks of a function,
+// tracking the constants held by registers and stack slots, and returns the syscall
+// numbers reaching each syscall site, indexed by the position of its line, alongside
+// the syscall numbers passed as arguments to other functions.
+func resolveSyscallSites(insts []instruction, arch *archSpec, convention callingConvention) (map[int]syscallSite, []callArgs) {
+	indexes := make(map[uint64]int, len(insts))
+	hasCalls := false
+	for i, inst := range insts {
+		if inst.op == "" {
+			continue
+		}
+		indexes[inst.addr] = i
+		hasCalls = hasCalls || isCall(inst) || arch.isSyscall(inst.text)
+	}
+
+	sites := make(map[int]syscallSite)
+	calls := make([]callArgs, 0)
+	if !hasCalls {
+		return sites, calls
+	}
+
+	blocks := splitBasicBlocks(insts, indexes)
+	states := solveDataFlow(blocks, insts, arch, entryState(arch, convention))
+
+	for b, block := range blocks {
+		state, computed := states[b]
+		if !computed {
+			continue
+		}
+		state = state.copy()
+
+		for i := block.start; i < block.end; i++ {
+			if containsSyscall(insts[i], arch) {
+				if site, ok := resolveSyscallSite(insts[i], state, arch, convention); ok {
+					sites[i] = site
+				}
+			} else if isDirectCall(insts[i]) {
+				if call, ok := getCallArgs(insts[i], state, arch, convention); ok {
+					call.index = i
+					calls = append(calls, call)
+				}
+			}
+			state.transfer(insts[i], arch)
+		}
+	}
+
+	return sites, calls
+}
+
+// entryState returns the state at the entry of a function, in which the argument
+// registers hold its parameters. Parameters passed on the stack are not tracked,
+// as their offsets change with the stack frame of the function.
+func entryState(arch *archSpec, convention callingConvention) flowState {
+	state := flowState{}
+	if convention == stackConvention {
+		return state
+	}
+
+	for pos, register := range arch.registerArgs {
+		state[register] = []int64{paramValue(pos)}
+	}
+
+	return state
+}
+
+// paramValue returns the value representing the parameter at the zero-based position pos.
+func paramValue(pos int) int64 {
+	return paramMarker * int64(pos+1)
+}
+
+// paramPosition returns the position of the parameter represented by the value, if any.
+func paramPosition(v int64) (int, bool) {
+	if v >= 0 || v%paramMarker != 0 {
+		return 0, false
+	}
+
+	return int(v/paramMarker) - 1, true
+}
+
+// splitBasicBlocks splits the instructions on branches and their targets.
+func splitBasicBlocks(insts []instruction, indexes map[uint64]int) []basicBlock {
+	leaders := map[int]bool{0: true}
+	for i, inst := range insts {
+		if target, ok := branchTarget(inst); ok {
+			if t, found := indexes[target]; found {
+				leaders[t] = true
+			}
+			leaders[i+1] = true
+		}
+		if isTerminator(inst) {
+			leaders[i+1] = true
+		}
+	}
+
+	starts := make([]int, 0, len(leaders))
+	for i := range leaders {
+		if i < len(insts) {
+			starts = append(starts, i)
+		}
+	}
+	sort.Ints(starts)
+
+	blockOf := make(map[int]int, len(starts))
+	blocks := make([]basicBlock, len(starts))
+	for b, start := range starts {
+		end := len(insts)
+		if b+1 < len(starts) {
+			end = starts[b+1]
+		}
+		blocks[b] = basicBlock{start: start, end: end}
+		blockOf[start] = b
+	}
+
+	for b := range blocks {
+		last := insts[blocks[b].end-1]
+		if target, ok := branchTarget(last); ok {
+			if t, found := indexes[target]; found {
+				blocks[b].succs = append(blocks[b].succs, blockOf[t])
+			}
+		}
+		if !isTerminator(last) && b+1 < len(blocks) {
+			blocks[b].succs = append(blocks[b].succs, b+1)
+		}
+	}
+
+	return blocks
+}
+
+// solveDataFlow returns the state at the entry of each basic block reachable from the first one,
+// which starts with the given state. Blocks without predecessors, e.g. reached through jump tables,
+// start with all values unknown.
+func solveDataFlow(blocks []basicBlock, insts []instruction, arch *archSpec, entry flowState) map[int]flowState {
+	hasPreds := make(map[int]bool, len(blocks))
+	for _, block := range blocks {
+		for _, s := range block.succs {
+			hasPreds[s] = true
+		}
+	}
+
+	states := make(map[int]flowState, len(blocks))
+	worklist := make([]int, 0, len(blocks))
+	for b := range blocks {
+		if b == 0 {
+			states[b] = entry.copy()
+			worklist = append(worklist, b)
+		} else if !hasPreds[b] {
+			states[b] = flowState{}
+			worklist = append(worklist, b)
+		}
+	}
+
+	for n := 0; len(worklist) > 0 && n < maxIterations; n++ {
+		b := worklist[0]
+		worklist = worklist[1:]
+
+		out := states[b].copy()
+		for i := blocks[b].start; i < blocks[b].end; i++ {
+			out.transfer(insts[i], arch)
+		}
+
+		for _, s := range blocks[b].succs {
+			in, computed := states[s]
+			if !computed {
+				states[s] = out.copy()
+				worklist = append(worklist, s)
+				continue
+			}
+			if merged, changed := in.meet(out); changed {
+				states[s] = merged
+				worklist = append(worklist, s)
+			}
+		}
+	}
+
+	return states
+}
+
+// resolveSyscallSite returns the syscall numbers held by the trap register of syscall
+// instructions, or by the trap argument of syscall wrappers.
+func resolveSyscallSite(inst instruction, state flowState, arch *archSpec, convention callingConvention) (syscallSite, bool) {
+	locations, isWrapper := getTrapArgLocations(inst, arch, convention)
+	if !isWrapper {
+		locations = []string{arch.trapRegister}
+	}
+
+	values, known := state.argValues(locations, arch)
+	if !known {
+		return syscallSite{}, false
+	}
+
+	return newSyscallSite(values, arch)
+}
+
+// newSyscallSite returns the syscall numbers, parameters and unknown syscall numbers
+// amongst the values reaching a syscall site.
+func newSyscallSite(values []int64, arch *archSpec) (syscallSite, bool) {
+	site := syscallSite{confidence: ConfidenceHigh}
+	for _, v := range values {
+		if pos, isParam := paramPosition(v); isParam {
+			site.params = append(site.params, pos)
+		} else if isSyscallID(v, arch) {
+			site.ids = append(site.ids, uint16(v))
+		} else if v >= 0 && v <= 0xffff {
+			site.unknownIDs = append(site.unknownIDs, uint16(v))
+		}
+	}
+	if len(values) > 1 {
+		site.confidence = ConfidenceMedium
+	}
+
+	return site, len(site.ids) > 0 || len(site.params) > 0 || len(site.unknownIDs) > 0
+}
+
+// getCallArgs returns the target of a direct call, alongside the syscall numbers and
+// parameters passed as its arguments. Calls to ABI0 implementations always use the stack.
+func getCallArgs(inst instruction, state flowState, arch *archSpec, convention callingConvention) (callArgs, bool) {
+	if inst.target == "" {
+		return callArgs{}, false
+	}
+	if strings.HasSuffix(inst.target, abi0Suffix) {
+		convention = stackConvention
+	}
+
+	call := callArgs{target: inst.target}
+	for pos := 0; pos < maxForwardedArgs; pos++ {
+		if values, known := state.argValues(convention.argLocations(arch, pos), arch); known {
+			if call.args == nil {
+				call.args = make(map[int][]int64)
+			}
+			call.args[pos] = values
+		}
+	}
+
+	return call, true
+}
+
+// argValues returns the values of the first location holding syscall numbers, falling back to
+// the first one holding parameters, as arguments may be stored in several locations when the
+// calling convention is not known.
+func (s flowState) argValues(locations []string, arch *archSpec) ([]int64, bool) {
+	var params []int64
+	for _, location := range locations {
+		values, known := s[location]
+		if !known {
+			continue
+		}
+
+		site, ok := newSyscallSite(values, arch)
+		if ok && len(site.ids) > 0 {
+			return values, true
+		}
+		if ok && params == nil {
+			params = values
+		}
+	}
+
+	return params, params != nil
+}
+
+func isSyscallID(v int64, arch *archSpec) bool {
+	if v < 0 || v > 0xffff {
+		return false
+	}
+
+	_, exists := arch.systemCalls[uint16(v)]
+	return exists
+}
+
+// transfer updates the state with the effects of the instruction.
+func (s flowState) transfer(inst instruction, arch *archSpec) {
+	op := inst.op
+	switch {
+	case op == "":
+		return
+	case isCall(inst):
+		s.clearCall(arch)
+		return
+	case arch.isSyscall(inst.text):
+		delete(s, arch.trapRegister)
+		return
+	case isReadOnly(inst):
+		return
+	}
+
+	dest := inst.args[len(inst.args)-1]
+	if dest == arch.stackArgs.register {
+		s.clearStack(arch)
+		s.setFrame(inst, arch)
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(op, "XOR") && len(inst.args) == 2 && inst.args[0] == inst.args[1]:
+		s[dest] = []int64{0}
+	case strings.HasPrefix(op, "MOV") && len(inst.args) == 2:
+		if values, known := s.valuesOf(inst.args[0], arch); known {
+			s[dest] = values
+		} else {
+			delete(s, dest)
+		}
+	case strings.HasPrefix(op, "ADD") && len(inst.args) == 3:
+		s.add(inst.args[0], inst.args[1], dest, arch)
+	default:
+		delete(s, dest)
+	}
+}
+
+// add handles additions of immediates, e.g. ADDI $94, X0, X17 on riscv64.
+func (s flowState) add(imm, src, dest string, arch *archSpec) {
+	n, isImm := parseImmediate(imm)
+	values, known := s.valuesOf(src, arch)
+	if !isImm || !known {
+		delete(s, dest)
+		return
+	}
+
+	sums := make([]int64, len(values))
+	for i, v := range values {
+		sums[i] = v + n
+	}
+	s[dest] = sums
+}
+
+// valuesOf returns the constants of an immediate, zero register or tracked location.
+func (s flowState) valuesOf(operand string, arch *archSpec) ([]int64, bool) {
+	if n, ok := parseImmediate(operand); ok {
+		return []int64{n}, true
+	}
+	if arch.zeroRegister != "" && operand == arch.zeroRegister {
+		return []int64{0}, true
+	}
+
+	values, known := s[operand]
+	return values, known
+}
+
+// clearStack forgets the values of all stack slots, as the stack pointer moved.
+func (s flowState) clearStack(arch *archSpec) {
+	delete(s, frameLocation)
+	for location := range s {
+		if _, isSlot := stackOffset(location, arch); isSlot {
+			delete(s, location)
+		}
+	}
+}
+
+// setFrame keeps the size of the stack frame allocated by the instruction, e.g. SUBQ $0x50, SP.
+func (s flowState) setFrame(inst instruction, arch *archSpec) {
+	if !strings.HasPrefix(inst.op, "SUB") || len(inst.args) == 3 && inst.args[1] != arch.stackArgs.register {
+		return
+	}
+
+	if size, ok := parseImmediate(inst.args[0]); ok && size > 0 {
+		s[frameLocation] = []int64{size}
+	}
+}
+
+// clearCall forgets the values clobbered by a call, which are all registers and the
+// stack slots below the stack frame size, where arguments of the callee may be stored.
+func (s flowState) clearCall(arch *archSpec) {
+	frame, hasFrame := s[frameLocation]
+	for location := range s {
+		if offset, isSlot := stackOffset(location, arch); hasFrame && isSlot && offset >= frame[0] ||
+			location == frameLocation {
+			continue
+		}
+		delete(s, location)
+	}
+}
+
+// stackOffset returns the offset of stack slots, e.g. 0x8(SP).
+func stackOffset(location string, arch *archSpec) (int64, bool) {
+	suffix := "(" + arch.stackArgs.register + ")"
+	if !strings.HasSuffix(location, suffix) {
+		return 0, false
+	}
+
+	offset, err := strconv.ParseInt(strings.TrimSuffix(location, suffix), 0, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return offset, true
+}
+
+func (s flowState) copy() flowState {
+	c := make(flowState, len(s))
+	for k, v := range s {
+		c[k] = v
+	}
+
+	return c
+}
+
+// meet merges the state of another path, keeping the locations known on both
+// and the union of their values, returning whether the state changed.
+func (s flowState) meet(other flowState) (flowState, bool) {
+	merged := make(flowState, len(s))
+	changed := false
+	for location, values := range s {
+		otherValues, known := other[location]
+		if !known {
+			changed = true
+			continue
+		}
+
+		union := unionValues(values, otherValues)
+		if len(union) > maxValues {
+			changed = true
+			continue
+		}
+		if len(union) != len(values) {
+			changed = true
+		}
+		merged[location] = union
+	}
+
+	return merged, changed
+}
+
+func unionValues(a, b []int64) []int64 {
+	union := append([]int64{}, a...)
+	for _, v := range b {
+		found := false
+		for _, u := range union {
+			if u == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			union = append(union, v)
+		}
+	}
+	sort.Slice(union, func(i, j int) bool { return union[i] < union[j] })
+
+	return union
+}
+
+// parseImmediate parses immediates such as $0x1, $94 or $-0x64.
+func parseImmediate(operand string) (int64, bool) {
+	if !strings.HasPrefix(operand, "$") {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(operand[1:], 0, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return n, true
+}
+
+// branchTarget returns the address of branches to addresses within the function,
+// which go tool objdump shows as a bare address as their last operand.
+func branchTarget(inst instruction) (uint64, bool) {
+	if len(inst.args) == 0 {
+		return 0, false
+	}
+
+	last := inst.args[len(inst.args)-1]
+	if !strings.HasPrefix(last, "0x") {
+		return 0, false
+	}
+
+	addr, err := strconv.ParseUint(last, 0, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return addr, true
+}
+
+// isCall checks whether the instruction calls another function.
+func isCall(inst instruction) bool {
+	switch inst.op {
+	case "CALL", "CALLQ", "BL", "BLX", "JAL":
+		return true
+	}
+
+	return false
+}
+
+// isTerminator checks whether execution does not fall through to the next instruction.
+func isTerminator(inst instruction) bool {
+	switch inst.op {
+	case "JMP", "B", "RET", "RETQ", "BR", "UD2", "UNDEF", "ERET":
+		return true
+	}
+
+	return false
+}
+
+// isReadOnly checks whether the instruction does not write into its last operand.
+func isReadOnly(inst instruction) bool {
+	if len(inst.args) == 0 {
+		return true
+	}
+	if _, isBranch := branchTarget(inst); isBranch {
+		return true
+	}
+
+	op := inst.op
+	if strings.HasPrefix(op, "J") || strings.HasPrefix(op, "CMP") || strings.HasPrefix(op, "TEST") ||
+		strings.HasPrefix(op, "PREFETCH") || strings.HasPrefix(op, "NOP") {
+		return true
+	}
+
+	switch op {
+	case "BT", "BTL", "BTQ", "BTW", "UCOMISD", "UCOMISS", "CMN", "TST", "PUSHQ", "PUSHL", "RET", "BR", "B":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,142 @@
+package systract
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestAnalyse_E2E_DataFlow(t *testing.T) {
+	should := should.New(t)
+	filePath, _ := filepath.Abs("../../test/dataflow.dump")
+
+	actual, err := Analyse(NewDumpReader(filePath))
+
+	should.NotError(err, "should analyse dump")
+	should.HaveSameItems(keys([]SystemCall{
+		{ID: 0, Name: "read"}, {ID: 39, Name: "getpid"}, {ID: 186, Name: "gettid"},
+	}), keys(actual.SystemCalls), "should resolve syscall numbers through data-flow")
+	should.BeEqual(map[string]Confidence{
+		"read":   ConfidenceHigh,
+		"getpid": ConfidenceMedium,
+		"gettid": ConfidenceMedium,
+	}, actual.Confidence, "should report the confidence of each syscall")
+	should.BeEqual([]Unresolved{
+		{Reason: UnresolvedDynamic, Location: Location{Symbol: "main.main", File: "/app/main.go", Line: 17, Address: 0x401029}},
+		{Reason: UnresolvedUnknownTarget, Location: Location{Symbol: "main.main", File: "/app/main.go", Line: 17, Address: 0x401024},
+			Target: "main.other"},
+	}, actual.Unresolved, "should report syscall numbers clobbered by calls as unresolved")
+}
+
+func TestResolveSyscallSites(t *testing.T) {
+	assertThat := func(assumption string, lines []string, expected map[int]syscallSite) {
+		should := should.New(t)
+
+		actual, _ := resolveSyscallSites(parseInstructions(lines, archs["amd64"]), archs["amd64"], unknownConvention)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should ignore constants not loaded into the trap register", []string{
+		"  sys.s:1	0x401000	bf01000000	MOVL $0x1, DI",
+		"  sys.s:2	0x401005	b8e7000000	MOVL $0xe7, AX",
+		"  sys.s:3	0x40100a	0f05		SYSCALL",
+	}, map[int]syscallSite{2: {ids: []uint16{231}, confidence: ConfidenceHigh}})
+
+	assertThat("should follow constants moved through registers", []string{
+		"  sys.s:1	0x401000	b927000000	MOVL $0x27, CX",
+		"  sys.s:2	0x401005	89c8		MOVL CX, AX",
+		"  sys.s:3	0x401007	0f05		SYSCALL",
+	}, map[int]syscallSite{2: {ids: []uint16{39}, confidence: ConfidenceHigh}})
+
+	assertThat("should use the trap argument of wrappers", []string{
+		"  sys.go:1	0x401000	48c7042410000000	MOVQ $0x10, 0(SP)",
+		"  sys.go:1	0x401008	48c744240803000000	MOVQ $0x3, 0x8(SP)",
+		"  sys.go:1	0x401011	e8ea000000	CALL syscall.Syscall(SB)",
+	}, map[int]syscallSite{2: {ids: []uint16{16}, confidence: ConfidenceHigh}})
+
+	assertThat("should not resolve values clobbered by calls", []string{
+		"  sys.s:1	0x401000	b8e7000000	MOVL $0xe7, AX",
+		"  sys.s:2	0x401005	e8f6000000	CALL main.other(SB)",
+		"  sys.s:3	0x40100a	0f05		SYSCALL",
+	}, map[int]syscallSite{})
+
+	assertThat("should not resolve values of other paths through loops", []string{
+		"  sys.s:1	0x401000	b8e7000000	MOVL $0xe7, AX",
+		"  sys.s:2	0x401005	0f05		SYSCALL",
+		"  sys.s:3	0x401007	488b0424	MOVQ 0(SP), AX",
+		"  sys.s:4	0x40100b	ebf8		JMP 0x401005",
+	}, map[int]syscallSite{})
+}
+
+func TestParseInstruction(t *testing.T) {
+	assertThat := func(assumption, line string, expected instruction, expectedOk bool) {
+		should := should.New(t)
+
+		actual, ok := parseInstruction(line)
+
+		should.BeEqual(expectedOk, ok, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should parse operands",
+		"  main.go:12		0x495e40		488d05b9a60400		MOVQ $0x1, 0x8(SP)	",
+		instruction{addr: 0x495e40, text: "MOVQ $0x1, 0x8(SP)", op: "MOVQ", args: []string{"$0x1", "0x8(SP)"}}, true)
+	assertThat("should parse instructions without operands",
+		"  sys_linux_amd64.s:53	0x453319		0f05			SYSCALL",
+		instruction{addr: 0x453319, text: "SYSCALL", op: "SYSCALL"}, true)
+	assertThat("should parse operands without spaces",
+		"  sys_linux_ppc64x.s:50	0x97184			380000ea		MOVD $234,R0",
+		instruction{addr: 0x97184, text: "MOVD $234,R0", op: "MOVD", args: []string{"$234", "R0"}}, true)
+	assertThat("should ignore relocations",
+		"  systrac.go:138	0x68e1			488d0d00000000		LEAQ 0(IP), CX			[3:7]R_PCREL:main.f·f",
+		instruction{addr: 0x68e1, text: "LEAQ 0(IP), CX", op: "LEAQ", args: []string{"0(IP)", "CX"}}, true)
+	assertThat("should not parse symbol definitions",
+		"TEXT main.main(SB) /app/main.go", instruction{}, false)
+}
+
+func TestFlowStateTransfer(t *testing.T) {
+	assertThat := func(assumption, arch, text string, state, expected flowState) {
+		should := should.New(t)
+		inst, _ := parseInstruction("  f.s:1	0x1000	00	" + text)
+
+		state.transfer(inst, archs[arch])
+
+		should.BeEqual(expected, state, assumption)
+	}
+
+	assertThat("should load immediates", "amd64", "MOVL $0x3, AX", flowState{}, flowState{"AX": {3}})
+	assertThat("should zero registers xored with themselves", "amd64", "XORL AX, AX", flowState{}, flowState{"AX": {0}})
+	assertThat("should forget overwritten locations", "amd64", "ADDQ $0x8, AX", flowState{"AX": {3}}, flowState{})
+	assertThat("should not change on comparisons", "amd64", "CMPQ AX, $0x3", flowState{"AX": {3}}, flowState{"AX": {3}})
+	assertThat("should forget stack slots when the stack pointer moves", "amd64", "SUBQ $0x18, SP",
+		flowState{"0(SP)": {3}, "AX": {3}}, flowState{"AX": {3}, frameLocation: {0x18}})
+	assertThat("should forget the frame size when the stack pointer moves", "amd64", "ADDQ $0x18, SP",
+		flowState{"0x20(SP)": {3}, frameLocation: {0x18}}, flowState{})
+	assertThat("should keep stack slots above the frame on calls", "amd64", "CALL main.f(SB)",
+		flowState{"0x8(SP)": {3}, "0x20(SP)": {3}, "AX": {3}, frameLocation: {0x18}},
+		flowState{"0x20(SP)": {3}, frameLocation: {0x18}})
+	assertThat("should forget all stack slots on calls when the frame is unknown", "amd64", "CALL main.f(SB)",
+		flowState{"0x20(SP)": {3}, "AX": {3}}, flowState{})
+	assertThat("should add immediates to the zero register", "riscv64", "ADDI $94, X0, X17",
+		flowState{}, flowState{"X17": {94}})
+	assertThat("should move the zero register", "arm64", "MOVD ZR, R8", flowState{}, flowState{"R8": {0}})
+}
+
+func TestFlowStateMeet(t *testing.T) {
+	assertThat := func(assumption string, state, other, expected flowState, expectedChanged bool) {
+		should := should.New(t)
+
+		actual, changed := state.meet(other)
+
+		should.BeEqual(expectedChanged, changed, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should keep equal values", flowState{"AX": {1}}, flowState{"AX": {1}}, flowState{"AX": {1}}, false)
+	assertThat("should join different values", flowState{"AX": {1}}, flowState{"AX": {2}}, flowState{"AX": {1, 2}}, true)
+	assertThat("should forget locations unknown on other path", flowState{"AX": {1}}, flowState{}, flowState{}, true)
+	assertThat("should forget locations with too many values", flowState{"AX": {1, 2, 3, 4}}, flowState{"AX": {5}},
+		flowState{}, true)
+}
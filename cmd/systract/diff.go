@@ -0,0 +1,58 @@
+package systract
+
+import (
+	"sort"
+	"strconv"
+)
+
+// DiffResult represents the system calls added and removed between two results.
+type DiffResult struct {
+	Added   []SystemCall `json:"added"`
+	Removed []SystemCall `json:"removed"`
+}
+
+// HasAdditions returns whether any system call was added.
+func (d DiffResult) HasAdditions() bool {
+	return len(d.Added) > 0
+}
+
+// Diff compares the system calls in a against the ones in b, returning
+// the ones only found in b as added and the ones only found in a as removed.
+// System calls are compared by name, so results from different architectures
+// can also be compared.
+func Diff(a, b []SystemCall) DiffResult {
+	return DiffResult{
+		Added:   missingSyscalls(b, a),
+		Removed: missingSyscalls(a, b),
+	}
+}
+
+// missingSyscalls returns the system calls in source which are not in target, sorted by name.
+func missingSyscalls(source, target []SystemCall) []SystemCall {
+	existing := make(map[string]bool, len(target))
+	for _, s := range target {
+		existing[syscallKey(s)] = true
+	}
+
+	missing := make([]SystemCall, 0)
+	for _, s := range source {
+		key := syscallKey(s)
+		if !existing[key] {
+			existing[key] = true
+			missing = append(missing, s)
+		}
+	}
+
+	sort.SliceStable(missing, func(i, j int) bool {
+		return missing[i].Name < missing[j].Name
+	})
+
+	return missing
+}
+
+func syscallKey(s SystemCall) string {
+	if s.Name == "" {
+		return "#" + strconv.Itoa(int(s.ID))
+	}
+	return s.Name
+}
@@ -0,0 +1,36 @@
+package systract
+
+import (
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestDiff(t *testing.T) {
+	assertThat := func(assumption string, a, b []SystemCall, expected DiffResult, expectedAdditions bool) {
+		should := should.New(t)
+
+		actual := Diff(a, b)
+
+		should.BeEqual(expected, actual, assumption)
+		should.BeEqual(expectedAdditions, actual.HasAdditions(), assumption)
+	}
+
+	read := SystemCall{ID: 0, Name: "read"}
+	write := SystemCall{ID: 1, Name: "write"}
+	ptrace := SystemCall{ID: 101, Name: "ptrace"}
+	exitGroup := SystemCall{ID: 231, Name: "exit_group"}
+
+	assertThat("should return no changes for same syscalls", []SystemCall{read, write}, []SystemCall{write, read},
+		DiffResult{Added: []SystemCall{}, Removed: []SystemCall{}}, false)
+	assertThat("should return added syscalls sorted by name", []SystemCall{read}, []SystemCall{read, write, ptrace, exitGroup},
+		DiffResult{Added: []SystemCall{exitGroup, ptrace, write}, Removed: []SystemCall{}}, true)
+	assertThat("should return removed syscalls", []SystemCall{read, write}, []SystemCall{write},
+		DiffResult{Added: []SystemCall{}, Removed: []SystemCall{read}}, false)
+	assertThat("should compare across architectures by name", []SystemCall{{ID: 94, Name: "exit_group"}}, []SystemCall{exitGroup},
+		DiffResult{Added: []SystemCall{}, Removed: []SystemCall{}}, false)
+	assertThat("should ignore duplicates", []SystemCall{}, []SystemCall{write, write},
+		DiffResult{Added: []SystemCall{write}, Removed: []SystemCall{}}, true)
+	assertThat("should compare syscalls without names by id", []SystemCall{{ID: 500}}, []SystemCall{{ID: 501}},
+		DiffResult{Added: []SystemCall{{ID: 501}}, Removed: []SystemCall{{ID: 500}}}, true)
+}
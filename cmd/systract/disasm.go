@@ -0,0 +1,370 @@
+package systract
+
+import (
+	"bufio"
+	"debug/elf"
+	"debug/gosym"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"golang.org/x/arch/arm/armasm"
+	"golang.org/x/arch/arm64/arm64asm"
+	"golang.org/x/arch/ppc64/ppc64asm"
+	"golang.org/x/arch/riscv64/riscv64asm"
+	"golang.org/x/arch/s390x/s390xasm"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// lookupFunc returns the symbol containing addr and its base address.
+type lookupFunc func(addr uint64) (name string, base uint64)
+
+// decodeFunc decodes the first instruction in code, which is located at pc,
+// returning its text in go assembly syntax and its size in bytes.
+type decodeFunc func(code []byte, pc uint64, lookup lookupFunc) (text string, size int)
+
+var decoders = map[string]decodeFunc{
+	"amd64":   decodeAMD64,
+	"arm64":   decodeARM64,
+	"386":     decode386,
+	"arm":     decodeARM,
+	"riscv64": decodeRISCV64,
+	"ppc64le": decodePPC64LE,
+	"s390x":   decodeS390X,
+}
+
+type elfSymbol struct {
+	name string
+	addr uint64
+	size uint64
+	text bool
+}
+
+// disassembler converts the text section of a go executable into
+// the same textual format generated by go tool objdump.
+type disassembler struct {
+	file      *elf.File
+	symbols   []elfSymbol
+	lines     *gosym.Table
+	text      []byte
+	textStart uint64
+	arch      string
+	byteOrder binary.ByteOrder
+	decode    decodeFunc
+	types     *typeNames
+	funcs     *funcValues
+}
+
+func newDisassembler(filePath string) (*disassembler, error) {
+	f, err := elf.Open(filePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open elf file")
+	}
+
+	d, err := loadDisassembler(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func loadDisassembler(f *elf.File) (*disassembler, error) {
+	arch := elfArch(f)
+	decode, found := decoders[arch]
+	if !found {
+		return nil, fmt.Errorf("unsupported architecture: %s", arch)
+	}
+
+	textSection := f.Section(".text")
+	if textSection == nil {
+		return nil, errors.New("text section not found")
+	}
+	text, err := textSection.Data()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read text section")
+	}
+
+	d := &disassembler{
+		file:      f,
+		text:      text,
+		textStart: textSection.Addr,
+		arch:      arch,
+		byteOrder: f.ByteOrder,
+		decode:    decode,
+	}
+
+	if err := d.loadLineTable(); err != nil {
+		return nil, err
+	}
+	d.loadSymbols()
+	d.types = newTypeNames(f, d.symbols)
+	d.funcs = newFuncValues(f, d.symbols)
+
+	return d, nil
+}
+
+func (d *disassembler) loadLineTable() error {
+	section := d.file.Section(".gopclntab")
+	if section == nil {
+		section = d.file.Section(".data.rel.ro.gopclntab")
+	}
+	if section == nil {
+		return errors.New("go pcln table not found, is this a go executable?")
+	}
+
+	pclntab, err := section.Data()
+	if err != nil {
+		return errors.Wrap(err, "could not read go pcln table")
+	}
+
+	textStart := d.textStart
+	if syms, err := d.file.Symbols(); err == nil {
+		for _, s := range syms {
+			if s.Name == "runtime.text" {
+				textStart = s.Value
+				break
+			}
+		}
+	}
+
+	d.lines, err = gosym.NewTable(nil, gosym.NewLineTable(pclntab, textStart))
+	if err != nil {
+		return errors.Wrap(err, "could not parse go pcln table")
+	}
+
+	return nil
+}
+
+// loadSymbols uses the elf symbol table when available, falling back
+// to the functions defined in the go pcln table for stripped executables.
+func (d *disassembler) loadSymbols() {
+	syms, err := d.file.Symbols()
+	if err != nil || len(syms) == 0 {
+		for _, fn := range d.lines.Funcs {
+			d.symbols = append(d.symbols, elfSymbol{
+				name: fn.Name,
+				addr: fn.Entry,
+				size: fn.End - fn.Entry,
+				text: true,
+			})
+		}
+	}
+
+	for _, s := range syms {
+		switch s.Name {
+		case "runtime.text", "runtime.etext", "text", "etext", "_text", "_etext":
+			continue
+		}
+
+		d.symbols = append(d.symbols, elfSymbol{
+			name: s.Name,
+			addr: s.Value,
+			size: s.Size,
+			text: d.isTextSection(s.Section),
+		})
+	}
+
+	sort.SliceStable(d.symbols, func(i, j int) bool {
+		return d.symbols[i].addr < d.symbols[j].addr
+	})
+}
+
+func (d *disassembler) isTextSection(index elf.SectionIndex) bool {
+	i := int(index)
+	if i <= 0 || i >= len(d.file.Sections) {
+		return false
+	}
+
+	flags := d.file.Sections[i].Flags & (elf.SHF_WRITE | elf.SHF_ALLOC | elf.SHF_EXECINSTR)
+	return flags == elf.SHF_ALLOC|elf.SHF_EXECINSTR
+}
+
+// lookup finds the symbol containing addr, falling back
+// to the names of itabs, type descriptors and function values.
+func (d *disassembler) lookup(addr uint64) (string, uint64) {
+	i := sort.Search(len(d.symbols), func(i int) bool { return addr < d.symbols[i].addr })
+	if i > 0 {
+		s := d.symbols[i-1]
+		if s.addr != 0 && s.addr <= addr && addr < s.addr+s.size {
+			return s.name, s.addr
+		}
+	}
+
+	if d.types != nil {
+		if name := d.types.name(addr); name != "" {
+			return name, addr
+		}
+	}
+
+	if d.funcs != nil {
+		if name := d.funcs.name(addr); name != "" {
+			return name, addr
+		}
+	}
+
+	return "", 0
+}
+
+// dump writes the disassembled text symbols into w.
+func (d *disassembler) dump(w io.Writer) error {
+	textEnd := d.textStart + uint64(len(d.text))
+	bw := bufio.NewWriter(w)
+	tw := tabwriter.NewWriter(bw, 18, 8, 1, '\t', tabwriter.StripEscape)
+
+	printed := false
+	for _, s := range d.symbols {
+		start, end := s.addr, s.addr+s.size
+		if !s.text || start < d.textStart || start >= textEnd {
+			continue
+		}
+		if end > textEnd {
+			end = textEnd
+		}
+
+		if printed {
+			fmt.Fprintf(bw, "\n")
+		}
+		printed = true
+
+		file, _, _ := d.lines.PCToLine(start)
+		fmt.Fprintf(bw, "TEXT %s(SB) %s\n", s.name, file)
+
+		code := d.text[:end-d.textStart]
+		for pc := start; pc < end; {
+			i := pc - d.textStart
+			text, size := d.decode(code[i:], pc, d.lookup)
+			j := i + uint64(size)
+			if j > uint64(len(d.text)) {
+				j = uint64(len(d.text))
+			}
+			file, line, _ := d.lines.PCToLine(pc)
+
+			fmt.Fprintf(tw, "  %s:%d\t%#x\t%s\t%s\t\n", base(file), line, pc, d.hex(d.text[i:j]), text)
+			pc += uint64(size)
+		}
+
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// hex returns the instruction encoding as bytes for x86 architectures
+// and as 32-bit words for all others, as go tool objdump does.
+func (d *disassembler) hex(code []byte) string {
+	if len(code)%4 != 0 || d.arch == "386" || d.arch == "amd64" {
+		return fmt.Sprintf("%x", code)
+	}
+
+	words := make([]string, 0, len(code)/4)
+	for i := 0; i < len(code); i += 4 {
+		words = append(words, fmt.Sprintf("%08x", d.byteOrder.Uint32(code[i:])))
+	}
+
+	return strings.Join(words, " ")
+}
+
+func (d *disassembler) close() error {
+	return d.file.Close()
+}
+
+func decodeAMD64(code []byte, pc uint64, lookup lookupFunc) (string, int) {
+	return decodeX86(code, pc, lookup, 64)
+}
+
+func decode386(code []byte, pc uint64, lookup lookupFunc) (string, int) {
+	return decodeX86(code, pc, lookup, 32)
+}
+
+func decodeX86(code []byte, pc uint64, lookup lookupFunc, mode int) (string, int) {
+	inst, err := x86asm.Decode(code, mode)
+	if err != nil || inst.Len == 0 || inst.Op == 0 {
+		return "?", 1
+	}
+
+	return x86asm.GoSyntax(inst, pc, x86asm.SymLookup(lookup)), inst.Len
+}
+
+func decodeARM(code []byte, pc uint64, lookup lookupFunc) (string, int) {
+	inst, err := armasm.Decode(code, armasm.ModeARM)
+	if err != nil || inst.Len == 0 || inst.Op == 0 {
+		return "?", 4
+	}
+
+	return armasm.GoSyntax(inst, pc, lookup, textReader{code, pc}), inst.Len
+}
+
+func decodeARM64(code []byte, pc uint64, lookup lookupFunc) (string, int) {
+	inst, err := arm64asm.Decode(code)
+	if err != nil || inst.Op == 0 {
+		return "?", 4
+	}
+
+	return arm64asm.GoSyntax(inst, pc, lookup, textReader{code, pc}), 4
+}
+
+func decodeRISCV64(code []byte, pc uint64, lookup lookupFunc) (string, int) {
+	inst, err := riscv64asm.Decode(code)
+	if err != nil || inst.Op == 0 {
+		return "?", 2
+	}
+
+	return riscv64asm.GoSyntax(inst, pc, lookup, textReader{code, pc}), inst.Len
+}
+
+func decodePPC64LE(code []byte, pc uint64, lookup lookupFunc) (string, int) {
+	inst, err := ppc64asm.Decode(code, binary.LittleEndian)
+	if err != nil || inst.Len == 0 {
+		return "?", 4
+	}
+
+	return ppc64asm.GoSyntax(inst, pc, lookup), inst.Len
+}
+
+func decodeS390X(code []byte, pc uint64, lookup lookupFunc) (string, int) {
+	inst, err := s390xasm.Decode(code)
+	if err != nil || inst.Len == 0 || inst.Op == 0 {
+		return "?", 2
+	}
+
+	return s390xasm.GoSyntax(inst, pc, lookup), inst.Len
+}
+
+// textReader provides access to the code following pc, which decoders
+// use to resolve pc-relative constants.
+type textReader struct {
+	code []byte
+	pc   uint64
+}
+
+func (r textReader) ReadAt(data []byte, off int64) (n int, err error) {
+	if off < 0 || uint64(off) < r.pc {
+		return 0, io.EOF
+	}
+
+	d := uint64(off) - r.pc
+	if d >= uint64(len(r.code)) {
+		return 0, io.EOF
+	}
+
+	n = copy(data, r.code[d:])
+	if n < len(data) {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return
+}
+
+// base returns the last element of path, regardless of the path separator used.
+func base(path string) string {
+	path = path[strings.LastIndex(path, "/")+1:]
+	return path[strings.LastIndex(path, `\`)+1:]
+}
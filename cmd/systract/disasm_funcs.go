@@ -0,0 +1,89 @@
+package systract
+
+import (
+	"debug/elf"
+	"encoding/binary"
+)
+
+// funcValueSuffix is appended to function names by the go toolchain to name
+// their function values, the read-only data referenced when taking their address.
+const funcValueSuffix string = "·f"
+
+// funcValues names the function values of executables which do not have them
+// in the symbol table, as done by newer go linkers.
+//
+// A function value is a pointer to the entry of the function, so data
+// pointing to the exact entry of a text symbol is named after it.
+type funcValues struct {
+	sections  []dataSection
+	ptrSize   uint64
+	byteOrder binary.ByteOrder
+	functions map[uint64]string
+}
+
+type dataSection struct {
+	addr uint64
+	data []byte
+}
+
+// newFuncValues returns nil when the executable has no read-only data.
+func newFuncValues(f *elf.File, symbols []elfSymbol) *funcValues {
+	v := &funcValues{
+		ptrSize:   8,
+		byteOrder: f.ByteOrder,
+		functions: make(map[uint64]string),
+	}
+	if f.Class == elf.ELFCLASS32 {
+		v.ptrSize = 4
+	}
+
+	for _, s := range f.Sections {
+		if s.Type != elf.SHT_PROGBITS || s.Flags&elf.SHF_ALLOC == 0 || s.Flags&elf.SHF_EXECINSTR != 0 {
+			continue
+		}
+		if s.Name == ".gopclntab" || s.Name == ".go.type" {
+			continue
+		}
+
+		data, err := s.Data()
+		if err != nil {
+			continue
+		}
+		v.sections = append(v.sections, dataSection{addr: s.Addr, data: data})
+	}
+	if len(v.sections) == 0 {
+		return nil
+	}
+
+	for _, s := range symbols {
+		if s.text {
+			v.functions[s.addr] = s.name
+		}
+	}
+
+	return v
+}
+
+// name returns the name of the function value at addr, e.g. main.main.func1·f.
+func (v *funcValues) name(addr uint64) string {
+	for _, s := range v.sections {
+		if addr < s.addr || addr-s.addr+v.ptrSize > uint64(len(s.data)) {
+			continue
+		}
+
+		i := addr - s.addr
+		entry := uint64(0)
+		if v.ptrSize == 4 {
+			entry = uint64(v.byteOrder.Uint32(s.data[i:]))
+		} else {
+			entry = v.byteOrder.Uint64(s.data[i:])
+		}
+
+		if fn, found := v.functions[entry]; found {
+			return fn + funcValueSuffix
+		}
+		return ""
+	}
+
+	return ""
+}
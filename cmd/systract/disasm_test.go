@@ -0,0 +1,113 @@
+package systract
+
+import (
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestDecodeAMD64(t *testing.T) {
+	lookup := func(addr uint64) (string, uint64) {
+		if addr == 0x401000 {
+			return "runtime.morestack_noctxt", 0x401000
+		}
+		return "", 0
+	}
+
+	assertThat := func(assumption string, code []byte, pc uint64, expectedText string, expectedSize int) {
+		should := should.New(t)
+
+		text, size := decodeAMD64(code, pc, lookup)
+
+		should.BeEqual(expectedText, text, assumption)
+		should.BeEqual(expectedSize, size, assumption)
+	}
+
+	assertThat("should decode SYSCALL instructions", []byte{0x0f, 0x05}, 0x453319, "SYSCALL", 2)
+	assertThat("should decode syscall ids moved into AX", []byte{0xb8, 0xe7, 0x00, 0x00, 0x00}, 0x453314, "MOVL $0xe7, AX", 5)
+	assertThat("should resolve call targets into symbol names", []byte{0xe8, 0xfb, 0xff, 0xff, 0xff}, 0x401000, "CALL runtime.morestack_noctxt(SB)", 5)
+	assertThat("should flag undecodable instructions", []byte{0x0f}, 0x401000, "?", 1)
+}
+
+func TestDecodeARM64(t *testing.T) {
+	lookup := func(addr uint64) (string, uint64) {
+		if addr == 0x89f20 {
+			return "runtime.exit.abi0", 0x89f20
+		}
+		return "", 0
+	}
+
+	assertThat := func(assumption string, code []byte, pc uint64, expectedText string) {
+		should := should.New(t)
+
+		text, size := decodeARM64(code, pc, lookup)
+
+		should.BeEqual(expectedText, text, assumption)
+		should.BeEqual(4, size, assumption)
+	}
+
+	assertThat("should decode SVC instructions", []byte{0x01, 0x00, 0x00, 0xd4}, 0x89f28, "SVC $0")
+	assertThat("should decode syscall ids moved into R8", []byte{0xc8, 0x0b, 0x80, 0xd2}, 0x89f24, "MOVD $94, R8")
+	assertThat("should resolve call targets into symbol names", []byte{0x00, 0x00, 0x00, 0x94}, 0x89f20, "CALL runtime.exit.abi0(SB)")
+	assertThat("should flag undecodable instructions", []byte{0x00, 0x00, 0x00, 0x00}, 0x89f20, "?")
+}
+
+func TestDecode386(t *testing.T) {
+	assertThat := func(assumption string, code []byte, expectedText string, expectedSize int) {
+		should := should.New(t)
+
+		text, size := decode386(code, 0x80cc1e0, func(uint64) (string, uint64) { return "", 0 })
+
+		should.BeEqual(expectedText, text, assumption)
+		should.BeEqual(expectedSize, size, assumption)
+	}
+
+	assertThat("should decode INT 0x80 instructions", []byte{0xcd, 0x80}, "INT $0x80", 2)
+	assertThat("should decode syscall ids moved into AX", []byte{0xb8, 0xfc, 0x00, 0x00, 0x00}, "MOVL $0xfc, AX", 5)
+}
+
+func TestDecodeARM(t *testing.T) {
+	assertThat := func(assumption string, code []byte, expectedText string) {
+		should := should.New(t)
+
+		text, size := decodeARM(code, 0x9faac, func(uint64) (string, uint64) { return "", 0 })
+
+		should.BeEqual(expectedText, text, assumption)
+		should.BeEqual(4, size, assumption)
+	}
+
+	assertThat("should decode SVC instructions", []byte{0x00, 0x00, 0x00, 0xef}, "SVC $0")
+	assertThat("should decode syscall ids moved into R7", []byte{0xf8, 0x70, 0xa0, 0xe3}, "MOVW $248, R7")
+}
+
+func TestDecodeOtherArchs(t *testing.T) {
+	assertThat := func(assumption string, decode decodeFunc, code []byte, expectedText string, expectedSize int) {
+		should := should.New(t)
+
+		text, size := decode(code, 0x71d50, func(uint64) (string, uint64) { return "", 0 })
+
+		should.BeEqual(expectedText, text, assumption)
+		should.BeEqual(expectedSize, size, assumption)
+	}
+
+	assertThat("should decode riscv64 ECALL instructions", decodeRISCV64, []byte{0x73, 0x00, 0x00, 0x00}, "ECALL", 4)
+	assertThat("should decode riscv64 syscall ids added into A7", decodeRISCV64, []byte{0x93, 0x08, 0xe0, 0x05}, "ADDI $94, X0, X17", 4)
+	assertThat("should decode ppc64le SC instructions", decodePPC64LE, []byte{0x02, 0x00, 0x00, 0x44}, "SC $0", 4)
+	assertThat("should decode ppc64le syscall ids moved into R0", decodePPC64LE, []byte{0xea, 0x00, 0x00, 0x38}, "MOVD $234,R0", 4)
+	assertThat("should decode s390x syscall instructions", decodeS390X, []byte{0x0a, 0x00}, "SYSALL $0", 2)
+	assertThat("should decode s390x syscall ids moved into R1", decodeS390X, []byte{0xa7, 0x19, 0x00, 0xf8}, "MOVH $248, R1", 4)
+}
+
+func TestBase(t *testing.T) {
+	assertThat := func(assumption, path, expected string) {
+		should := should.New(t)
+
+		actual := base(path)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should return file name of unix paths", "/usr/local/go/src/runtime/sys_linux_amd64.s", "sys_linux_amd64.s")
+	assertThat("should return file name of windows paths", `C:\go\src\runtime\proc.go`, "proc.go")
+	assertThat("should return file names as is", "<autogenerated>", "<autogenerated>")
+}
@@ -0,0 +1,237 @@
+package systract
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"strings"
+)
+
+const (
+	// tflagExtraStar marks type names which have a superfluous leading star.
+	tflagExtraStar byte = 1 << 1
+	// maxItabMethods bounds the number of methods an itab is expected to have.
+	maxItabMethods uint64 = 1024
+	// unreachableMethod replaces methods removed by the linker dead code elimination.
+	unreachableMethod string = "runtime.unreachableMethod"
+)
+
+// typeNames names itabs and type descriptors of executables which do not
+// have them in the symbol table, as done by newer go linkers.
+//
+// It relies on the runtime layouts of itab, abi.Type and abi.InterfaceType,
+// which have been stable across go versions, and on type names being encoded
+// with a varint length, as done since go 1.17.
+type typeNames struct {
+	data       []byte
+	start, end uint64
+	ptrSize    uint64
+	byteOrder  binary.ByteOrder
+	functions  map[uint64]string
+	receivers  map[string]string
+	names      map[uint64]string
+}
+
+// newTypeNames returns nil when the type descriptors cannot be located.
+func newTypeNames(f *elf.File, symbols []elfSymbol) *typeNames {
+	var start, end uint64
+	for _, s := range symbols {
+		switch s.name {
+		case "runtime.types":
+			start = s.addr
+		case "runtime.etypes":
+			end = s.addr
+		}
+	}
+	if start == 0 || end <= start {
+		return nil
+	}
+
+	data := readAddress(f, start, end-start)
+	if data == nil {
+		return nil
+	}
+
+	t := &typeNames{
+		data:      data,
+		start:     start,
+		end:       end,
+		ptrSize:   8,
+		byteOrder: f.ByteOrder,
+		functions: make(map[uint64]string),
+		receivers: make(map[string]string),
+		names:     make(map[uint64]string),
+	}
+	if f.Class == elf.ELFCLASS32 {
+		t.ptrSize = 4
+	}
+
+	for _, s := range symbols {
+		if !s.text {
+			continue
+		}
+		t.functions[s.addr] = s.name
+
+		if receiver, ok := receiverType(s.name); ok {
+			short := receiver[strings.LastIndex(receiver, "/")+1:]
+			if existing, found := t.receivers[short]; found && existing != receiver {
+				t.receivers[short] = ""
+				continue
+			}
+			t.receivers[short] = receiver
+		}
+	}
+
+	return t
+}
+
+// readAddress returns size bytes of the file contents at the virtual address addr.
+func readAddress(f *elf.File, addr, size uint64) []byte {
+	for _, s := range f.Sections {
+		if s.Type == elf.SHT_NOBITS || addr < s.Addr || addr+size > s.Addr+s.Size {
+			continue
+		}
+
+		data := make([]byte, size)
+		if _, err := s.ReadAt(data, int64(addr-s.Addr)); err != nil {
+			return nil
+		}
+		return data
+	}
+
+	return nil
+}
+
+// name returns the name of the itab or type descriptor at addr,
+// e.g. go:itab.*os.File,io.Writer or type:*os.File.
+func (t *typeNames) name(addr uint64) string {
+	if name, found := t.names[addr]; found {
+		return name
+	}
+
+	name := ""
+	if concrete, iface, ok := t.itab(addr); ok {
+		name = "go:itab." + concrete + "," + iface
+	} else if typeName, ok := t.typeString(addr); ok {
+		name = "type:" + t.fullName(typeName)
+	}
+
+	t.names[addr] = name
+	return name
+}
+
+// itab returns the concrete type and the interface of the itab at addr.
+func (t *typeNames) itab(addr uint64) (string, string, bool) {
+	inter, ok1 := t.pointer(addr)
+	typ, ok2 := t.pointer(addr + t.ptrSize)
+	if !ok1 || !ok2 || !t.contains(inter) || !t.contains(typ) {
+		return "", "", false
+	}
+
+	methods, ok := t.pointer(inter + t.typeSize() + 2*t.ptrSize)
+	if !ok || methods == 0 || methods > maxItabMethods {
+		return "", "", false
+	}
+
+	concrete := ""
+	fun := addr + 2*t.ptrSize + 8
+	for i := uint64(0); i < methods; i++ {
+		entry, ok := t.pointer(fun + i*t.ptrSize)
+		if !ok {
+			return "", "", false
+		}
+		method, found := t.functions[entry]
+		if !found {
+			return "", "", false
+		}
+		if receiver, ok := receiverType(method); ok && concrete == "" && method != unreachableMethod {
+			concrete = receiver
+		}
+	}
+
+	typeName, ok1 := t.typeString(typ)
+	iface, ok2 := t.typeString(inter)
+	if !ok1 || !ok2 {
+		return "", "", false
+	}
+
+	if concrete == "" {
+		return t.fullName(typeName), iface, true
+	}
+	if strings.HasPrefix(typeName, "*") {
+		concrete = "*" + concrete
+	}
+
+	return concrete, iface, true
+}
+
+// typeString returns the name of the type descriptor at addr, as in reflect.Type.String.
+func (t *typeNames) typeString(addr uint64) (string, bool) {
+	p := t.ptrSize
+	if !t.contains(addr) || addr+t.typeSize() > t.end {
+		return "", false
+	}
+
+	i := addr - t.start
+	tflag := t.data[i+2*p+4]
+	kind := t.data[i+2*p+7] & 0x1f
+	if kind == 0 || kind > 26 {
+		return "", false
+	}
+
+	nameAddr := t.start + uint64(t.byteOrder.Uint32(t.data[i+4*p+8:]))
+	if !t.contains(nameAddr) {
+		return "", false
+	}
+
+	// names are encoded as a flags byte followed by the varint length.
+	n, size := binary.Uvarint(t.data[nameAddr-t.start+1:])
+	begin := nameAddr - t.start + 1 + uint64(size)
+	if size <= 0 || n == 0 || n > 1024 || begin+n > uint64(len(t.data)) {
+		return "", false
+	}
+
+	name := string(t.data[begin : begin+n])
+	for _, c := range name {
+		if c < ' ' || c > '~' {
+			return "", false
+		}
+	}
+
+	if tflag&tflagExtraStar != 0 {
+		name = name[1:]
+	}
+
+	return name, name != ""
+}
+
+// fullName replaces the package name in type names with the package path,
+// when it can be found unambiguously amongst the receivers of methods.
+func (t *typeNames) fullName(typeName string) string {
+	base := strings.TrimPrefix(typeName, "*")
+	if receiver := t.receivers[base]; receiver != "" {
+		return typeName[:len(typeName)-len(base)] + receiver
+	}
+
+	return typeName
+}
+
+func (t *typeNames) pointer(addr uint64) (uint64, bool) {
+	if !t.contains(addr) || addr+t.ptrSize > t.end {
+		return 0, false
+	}
+
+	i := addr - t.start
+	if t.ptrSize == 4 {
+		return uint64(t.byteOrder.Uint32(t.data[i:])), true
+	}
+	return t.byteOrder.Uint64(t.data[i:]), true
+}
+
+func (t *typeNames) contains(addr uint64) bool {
+	return addr >= t.start && addr < t.end
+}
+
+// typeSize returns the size of abi.Type.
+func (t *typeNames) typeSize() uint64 {
+	return 4*t.ptrSize + 16
+}
@@ -0,0 +1,103 @@
+package systract
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+const typesStart uint64 = 0x1000
+
+// newTestTypeNames returns 64-bit type descriptors containing the interface
+// io.Writer at 0x1000, the type *main.file at 0x1080, the type main.file at
+// 0x10c0 and the itab for *main.file and io.Writer at 0x1100.
+func newTestTypeNames() *typeNames {
+	data := make([]byte, 0x300)
+	writeType := func(offset uint64, tflag, kind byte, nameOffset uint32, name string) {
+		data[offset+20] = tflag
+		data[offset+23] = kind
+		binary.LittleEndian.PutUint32(data[offset+40:], nameOffset)
+		data[nameOffset+1] = byte(len(name))
+		copy(data[nameOffset+2:], name)
+	}
+
+	writeType(0x0, 0, 20, 0x200, "io.Writer")
+	binary.LittleEndian.PutUint64(data[0x0+64:], 1)
+	writeType(0x80, 0, 22, 0x220, "*main.file")
+	writeType(0xc0, tflagExtraStar, 25, 0x220, "*main.file")
+
+	binary.LittleEndian.PutUint64(data[0x100:], typesStart)
+	binary.LittleEndian.PutUint64(data[0x108:], typesStart+0x80)
+	binary.LittleEndian.PutUint64(data[0x118:], 0x401000)
+
+	return &typeNames{
+		data:      data,
+		start:     typesStart,
+		end:       typesStart + uint64(len(data)),
+		ptrSize:   8,
+		byteOrder: binary.LittleEndian,
+		functions: map[uint64]string{0x401000: "example.com/app/internal.(*file).Write"},
+		receivers: map[string]string{"main.file": "main.file", "internal.file": "example.com/app/internal.file"},
+		names:     make(map[uint64]string),
+	}
+}
+
+func TestTypeNames_Name(t *testing.T) {
+	assertThat := func(assumption string, addr uint64, expected string) {
+		should := should.New(t)
+		types := newTestTypeNames()
+
+		actual := types.name(addr)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should name itabs after the receiver of their methods", typesStart+0x100,
+		"go:itab.*example.com/app/internal.file,io.Writer")
+	assertThat("should name pointer types", typesStart+0x80, "type:*main.file")
+	assertThat("should remove extra star from named types", typesStart+0xc0, "type:main.file")
+	assertThat("should name interface types", typesStart, "type:io.Writer")
+	assertThat("should return empty for addresses without types", typesStart+0x200, "")
+	assertThat("should return empty for addresses out of range", 0x401000, "")
+}
+
+func TestTypeNames_FullName(t *testing.T) {
+	assertThat := func(assumption, typeName, expected string) {
+		should := should.New(t)
+		types := newTestTypeNames()
+
+		actual := types.fullName(typeName)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should replace package name with its path", "internal.file", "example.com/app/internal.file")
+	assertThat("should keep pointers", "*internal.file", "*example.com/app/internal.file")
+	assertThat("should keep unknown types", "os.File", "os.File")
+}
+
+func TestFuncValues_Name(t *testing.T) {
+	data := make([]byte, 0x20)
+	binary.LittleEndian.PutUint64(data[0x8:], 0x401000)
+	binary.LittleEndian.PutUint64(data[0x10:], 0x401004)
+	values := &funcValues{
+		sections:  []dataSection{{addr: 0x500000, data: data}},
+		ptrSize:   8,
+		byteOrder: binary.LittleEndian,
+		functions: map[uint64]string{0x401000: "main.main.func1"},
+	}
+
+	assertThat := func(assumption string, addr uint64, expected string) {
+		should := should.New(t)
+
+		actual := values.name(addr)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should name data pointing to function entries", 0x500008, "main.main.func1·f")
+	assertThat("should return empty for data pointing elsewhere", 0x500010, "")
+	assertThat("should return empty for data out of range", 0x50001c, "")
+	assertThat("should return empty for addresses before the sections", 0x400000, "")
+}
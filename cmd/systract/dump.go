@@ -30,3 +30,18 @@ func (d *DumpReader) GetReader() (io.ReadCloser, error) {
 	/* #nosec filePath is pre-processed by sanitiseFileName */
 	return os.Open(filePath)
 }
+
+// arch returns the architecture detected from the contents of the dump file
+func (d *DumpReader) arch() (string, error) {
+	filePath, err := sanitiseFileName(d.filePath)
+	if err != nil {
+		return "", err
+	}
+
+	return getDumpArch(filePath)
+}
+
+// file returns the path of the dump file
+func (d *DumpReader) file() (string, error) {
+	return sanitiseFileName(d.filePath)
+}
@@ -0,0 +1,79 @@
+package systract
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// ELFReader represents a go executables reader.
+// Differently from ExeReader, it disassembles the executable in-process,
+// removing the dependency on the go tools.
+type ELFReader struct {
+	filePath string
+}
+
+// NewELFReader initialises a new ELFReader
+func NewELFReader(exeFilePath string) *ELFReader {
+	return &ELFReader{exeFilePath}
+}
+
+// GetReader returns a io.ReadCloser with the disassembled dump of the executable
+func (e *ELFReader) GetReader() (io.ReadCloser, error) {
+	filePath, err := sanitiseFileName(e.filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !fileExists(filePath) {
+		return nil, errors.New("file does not exist or permission denied")
+	}
+
+	d, err := newDisassembler(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		err := d.dump(writer)
+		d.close()
+		writer.CloseWithError(err)
+	}()
+
+	return reader, nil
+}
+
+// arch returns the architecture defined in the elf header of the executable
+func (e *ELFReader) arch() (string, error) {
+	filePath, err := sanitiseFileName(e.filePath)
+	if err != nil {
+		return "", err
+	}
+
+	return getELFArch(filePath)
+}
+
+// goVersion returns the go version used to build the executable
+func (e *ELFReader) goVersion() (string, error) {
+	filePath, err := sanitiseFileName(e.filePath)
+	if err != nil {
+		return "", err
+	}
+
+	return getBuildGoVersion(filePath)
+}
+
+// modules returns the modules used to build the executable
+func (e *ELFReader) modules() ([]string, error) {
+	filePath, err := sanitiseFileName(e.filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return getBuildModules(filePath)
+}
+
+// file returns the path of the executable
+func (e *ELFReader) file() (string, error) {
+	return sanitiseFileName(e.filePath)
+}
@@ -0,0 +1,154 @@
+package systract
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestELFReader_GetReader_Integration(t *testing.T) {
+	assertThat := func(assumption, filePath string, expectedErr bool) {
+		should := should.New(t)
+		reader := NewELFReader(filePath)
+
+		r, err := reader.GetReader()
+		if r != nil {
+			r.Close()
+		}
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+	}
+
+	assertThat("should error when file not found",
+		"file-that-dont-exist",
+		true)
+	assertThat("should error when file is not an elf executable",
+		"../../test/single-syscall.dump",
+		true)
+	assertThat("should be able disassemble go executables",
+		"../../test/simple-app",
+		false)
+
+	// test the handling of current chdir being deleted
+	wdSnapshot, _ := os.Getwd()
+	tmpFolder, err := ioutil.TempDir("", "zaz-test")
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	os.Chdir(tmpFolder)
+	os.Remove(tmpFolder)
+
+	assertThat("should error if current directly disappears",
+		"any-file", true)
+
+	// returns snapshotted working directory to ensure other tests' repeatability
+	os.Chdir(wdSnapshot)
+}
+
+func TestELFReader_GetReader_ObjdumpFormat(t *testing.T) {
+	should := should.New(t)
+	reader, err := NewELFReader("../../test/simple-app").GetReader()
+	should.NotError(err, "should disassemble simple-app")
+	defer reader.Close()
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Scan()
+	header := scanner.Text()
+	scanner.Scan()
+	instruction := scanner.Text()
+
+	should.BeEqual("TEXT internal/cpu.Initialize(SB) /usr/local/go/src/internal/cpu/cpu.go", header,
+		"should start with the first text symbol")
+	should.BeEqual("  cpu.go:141\t\t0x401000\t\t64488b0c25f8ffffff\tMOVQ FS:0xfffffff8, CX\t\t\t", instruction,
+		"should follow go tool objdump instruction format")
+}
+
+func TestExtract_E2E_ELFReader(t *testing.T) {
+	should := should.New(t)
+
+	expected, err := Extract(NewExeReader("../../test/simple-app"))
+	should.NotError(err, "should extract syscalls through go tool objdump")
+
+	// go tool objdump does not name some functions loaded as values by LEAQ, e.g. the
+	// netpoller initialisation run through sync.Once, whose syscalls only the ELFReader finds.
+	expected = append(expected, SystemCall{ID: 213, Name: "epoll_create"}, SystemCall{ID: 291, Name: "epoll_create1"})
+
+	actual, err := Extract(NewELFReader("../../test/simple-app"))
+
+	should.NotError(err, "should extract syscalls without go tool objdump")
+	should.HaveSameItems(keys(expected), keys(actual), "should find the same syscalls as go tool objdump")
+}
+
+func TestELFReader_GetReader_ObjdumpParity(t *testing.T) {
+	should := should.New(t)
+	expected := readDumpLines(t, NewExeReader("../../test/simple-app"))
+	actual := readDumpLines(t, NewELFReader("../../test/simple-app"))
+
+	var mismatches []string
+	for i := 0; i < len(expected) || i < len(actual); i++ {
+		var e, a string
+		if i < len(expected) {
+			e = expected[i]
+		}
+		if i < len(actual) {
+			a = actual[i]
+		}
+		if e != a && !sameInstruction(e, a) {
+			mismatches = append(mismatches, fmt.Sprintf("line %d: expected %q, got %q", i+1, e, a))
+		}
+	}
+
+	if len(mismatches) > 5 {
+		mismatches = append(mismatches[:5], fmt.Sprintf("and %d more", len(mismatches)-5))
+	}
+	should.BeEqual([]string(nil), mismatches, "should disassemble the same instructions as go tool objdump")
+}
+
+// ipRelativeRegex matches the addresses go tool objdump leaves unnamed, e.g. 0xc54c0(IP).
+var ipRelativeRegex = regexp.MustCompile(`0x[0-9a-f]+\(IP\)`)
+
+// sameInstruction returns whether the lines only differ by the ELFReader naming the
+// funcvals and types go tool objdump leaves as addresses, e.g. runtime.aeshash32·f(SB).
+func sameInstruction(expected, actual string) bool {
+	parts := ipRelativeRegex.Split(expected, -1)
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+
+	return regexp.MustCompile("^" + strings.Join(parts, `\S+\(SB\)`) + "$").MatchString(actual)
+}
+
+// readDumpLines returns the lines of the dump of the source, with the fields of
+// each line separated by a single tab, as column widths differ between dumps.
+func readDumpLines(t *testing.T, source SourceReader) []string {
+	reader, err := source.GetReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		var fields []string
+		for _, field := range strings.Split(scanner.Text(), "\t") {
+			if field = strings.TrimSpace(field); field != "" {
+				fields = append(fields, field)
+			}
+		}
+		lines = append(lines, strings.Join(fields, "\t"))
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	return lines
+}
@@ -34,6 +34,16 @@ func (e *ExeReader) GetReader() (io.ReadCloser, error) {
 	return getFileDumpReader(objDumpFilePath, filePath)
 }
 
+// arch returns the architecture defined in the elf header of the executable
+func (e *ExeReader) arch() (string, error) {
+	filePath, err := sanitiseFileName(e.filePath)
+	if err != nil {
+		return "", err
+	}
+
+	return getELFArch(filePath)
+}
+
 func getObjDumpFilePath() string {
 	return fmt.Sprintf("/usr/local/go/pkg/tool/%s_%s/objdump", runtime.GOOS, runtime.GOARCH)
 }
@@ -52,3 +62,28 @@ func getFileDumpReader(objDumpFilePath, filePath string) (io.ReadCloser, error)
 
 	return output, err
 }
+
+// goVersion returns the go version used to build the executable
+func (e *ExeReader) goVersion() (string, error) {
+	filePath, err := sanitiseFileName(e.filePath)
+	if err != nil {
+		return "", err
+	}
+
+	return getBuildGoVersion(filePath)
+}
+
+// modules returns the modules used to build the executable
+func (e *ExeReader) modules() ([]string, error) {
+	filePath, err := sanitiseFileName(e.filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return getBuildModules(filePath)
+}
+
+// file returns the path of the executable
+func (e *ExeReader) file() (string, error) {
+	return sanitiseFileName(e.filePath)
+}
@@ -0,0 +1,160 @@
+package systract
+
+import (
+	"sort"
+)
+
+// GraphOptions defines which part of the call graph is exported by ExportGraph.
+type GraphOptions struct {
+	// CollapsePackages merges the symbols of each package into a single node.
+	CollapsePackages bool
+	// Syscalls restricts the graph to the paths reaching the system calls, by name or id.
+	// All system calls are considered when empty.
+	Syscalls []string
+}
+
+// Graph represents the calls between the entry points of a source and the symbols
+// issuing system calls.
+type Graph struct {
+	Arch  string      `json:"arch"`
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode represents a symbol, or a package when collapsed.
+type GraphNode struct {
+	// ID is the symbol name, or the package path when collapsed.
+	ID      string `json:"id"`
+	Package string `json:"package"`
+	// EntryPoint is set for entry points, or packages containing them when collapsed.
+	EntryPoint bool `json:"entryPoint,omitempty"`
+	// SystemCalls contains the system calls issued directly by the symbol, or
+	// by any symbol of the package when collapsed, sorted by name.
+	SystemCalls []SystemCall `json:"systemCalls,omitempty"`
+}
+
+// GraphEdge represents the calls from a node to another.
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// ExportGraph returns the subgraph of the calls from the entry points of the source down
+// to the symbols issuing system calls, leaving out all symbols which reach none of them.
+// Nodes and edges are sorted, so that the same source is always exported alike.
+func ExportGraph(source SourceReader, opts GraphOptions) (*Graph, error) {
+	arch, symbols, err := parseSource(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var wanted map[uint16]bool
+	if len(opts.Syscalls) > 0 {
+		wanted = make(map[uint16]bool, len(opts.Syscalls))
+		for _, syscall := range opts.Syscalls {
+			id, err := resolveSyscallID(syscall, arch)
+			if err != nil {
+				return nil, err
+			}
+			wanted[id] = true
+		}
+	}
+
+	roots := findRuntimeRoots(symbols, arch.name, getGoVersion(source))
+	entryPoints := getEntryPoints(symbols, roots)
+	included := reachingSymbols(symbols, entryPoints, wanted)
+
+	graph := &Graph{Arch: arch.name, Nodes: make([]GraphNode, 0), Edges: make([]GraphEdge, 0)}
+	nodeOf := func(symbol string) string {
+		if opts.CollapsePackages {
+			return packagePath(symbol)
+		}
+		return symbol
+	}
+
+	isEntryPoint := make(map[string]bool, len(entryPoints))
+	for _, ep := range entryPoints {
+		isEntryPoint[ep] = true
+	}
+
+	nodes := make(map[string]*GraphNode)
+	syscalls := make(map[string]map[uint16]bool)
+	edges := make(map[GraphEdge]bool)
+	for name := range included {
+		id := nodeOf(name)
+		node, found := nodes[id]
+		if !found {
+			node = &GraphNode{ID: id, Package: packagePath(name)}
+			nodes[id] = node
+			syscalls[id] = make(map[uint16]bool)
+		}
+		node.EntryPoint = node.EntryPoint || isEntryPoint[name]
+
+		s := symbols[name]
+		for _, syscall := range s.syscallIDs {
+			if wanted == nil || wanted[syscall] {
+				syscalls[id][syscall] = true
+			}
+		}
+
+		for _, callee := range s.subCalls {
+			edge := GraphEdge{From: id, To: nodeOf(callee)}
+			if included[callee] && (edge.From != edge.To || !opts.CollapsePackages) {
+				edges[edge] = true
+			}
+		}
+	}
+
+	for id, node := range nodes {
+		if len(syscalls[id]) > 0 {
+			node.SystemCalls = toSystemCalls(syscalls[id], arch)
+		}
+		graph.Nodes = append(graph.Nodes, *node)
+	}
+	for edge := range edges {
+		graph.Edges = append(graph.Edges, edge)
+	}
+
+	sort.Slice(graph.Nodes, func(i, j int) bool {
+		return graph.Nodes[i].ID < graph.Nodes[j].ID
+	})
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		a, b := graph.Edges[i], graph.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+
+	return graph, nil
+}
+
+// reachingSymbols returns the symbols reachable from the entry points which reach any
+// of the wanted system calls, or any system call at all when wanted is nil. As callers
+// reach all that their callees do, only paths through such symbols need walking.
+func reachingSymbols(symbols map[string]symbolDefinition, entryPoints []string, wanted map[uint16]bool) map[string]bool {
+	graph := newCallGraph(symbols)
+	reaches := func(symbol string) bool {
+		for _, id := range graph.reachableSyscalls(symbol) {
+			if wanted == nil || wanted[id] {
+				return true
+			}
+		}
+		return false
+	}
+
+	included := make(map[string]bool)
+	pending := append([]string{}, entryPoints...)
+	for len(pending) > 0 {
+		name := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if included[name] || !reaches(name) {
+			continue
+		}
+
+		included[name] = true
+		pending = append(pending, symbols[name].subCalls...)
+	}
+
+	return included
+}
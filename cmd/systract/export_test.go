@@ -0,0 +1,90 @@
+package systract
+
+import (
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestExportGraph(t *testing.T) {
+	assertThat := func(assumption, fileName string, opts GraphOptions, expected *Graph, expectedErr bool) {
+		should := should.New(t)
+
+		actual, err := ExportGraph(NewDumpReader(fileName), opts)
+
+		should.BeEqual(expectedErr, err != nil, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	getpid := SystemCall{ID: 39, Name: "getpid"}
+	assertThat("should export the calls from entry points down to syscall sites",
+		"../../test/go1.21-syscalls.dump", GraphOptions{},
+		&Graph{
+			Arch: "amd64",
+			Nodes: []GraphNode{
+				{ID: "main.main", Package: "main", EntryPoint: true, SystemCalls: []SystemCall{{ID: 58, Name: "vfork"}}},
+				{ID: "runtime.exit.abi0", Package: "runtime", SystemCalls: []SystemCall{{ID: 231, Name: "exit_group"}}},
+				{ID: "syscall.Getpid", Package: "syscall", SystemCalls: []SystemCall{getpid}},
+			},
+			Edges: []GraphEdge{{From: "main.main", To: "runtime.exit.abi0"}, {From: "main.main", To: "syscall.Getpid"}},
+		}, false)
+	assertThat("should restrict to paths reaching the syscalls by name",
+		"../../test/go1.21-syscalls.dump", GraphOptions{Syscalls: []string{"getpid"}},
+		&Graph{
+			Arch: "amd64",
+			Nodes: []GraphNode{
+				{ID: "main.main", Package: "main", EntryPoint: true},
+				{ID: "syscall.Getpid", Package: "syscall", SystemCalls: []SystemCall{getpid}},
+			},
+			Edges: []GraphEdge{{From: "main.main", To: "syscall.Getpid"}},
+		}, false)
+	assertThat("should restrict to paths reaching the syscalls by id",
+		"../../test/go1.21-syscalls.dump", GraphOptions{Syscalls: []string{"39"}},
+		&Graph{
+			Arch: "amd64",
+			Nodes: []GraphNode{
+				{ID: "main.main", Package: "main", EntryPoint: true},
+				{ID: "syscall.Getpid", Package: "syscall", SystemCalls: []SystemCall{getpid}},
+			},
+			Edges: []GraphEdge{{From: "main.main", To: "syscall.Getpid"}},
+		}, false)
+	assertThat("should collapse symbols by package",
+		"../../test/interface-calls.dump", GraphOptions{CollapsePackages: true},
+		&Graph{
+			Arch: "amd64",
+			Nodes: []GraphNode{
+				{ID: "main", Package: "main", EntryPoint: true, SystemCalls: []SystemCall{getpid, {ID: 1, Name: "write"}}},
+			},
+			Edges: []GraphEdge{},
+		}, false)
+	assertThat("should error for unknown syscalls",
+		"../../test/go1.21-syscalls.dump", GraphOptions{Syscalls: []string{"not_a_syscall"}}, nil, true)
+	assertThat("should error when input file does not exist",
+		"../../test/non-existent.dump", GraphOptions{}, nil, true)
+}
+
+func TestReachingSymbols(t *testing.T) {
+	symbols := map[string]symbolDefinition{
+		"main.main":   {subCalls: []string{"main.log", "main.write", "main.exit", "fmt.missing"}},
+		"main.log":    {subCalls: []string{"main.format"}},
+		"main.format": {},
+		"main.write":  {syscallIDs: []uint16{1}, subCalls: []string{"main.write"}},
+		"main.exit":   {syscallIDs: []uint16{231}},
+		"main.unused": {syscallIDs: []uint16{1}},
+	}
+
+	assertThat := func(assumption string, wanted map[uint16]bool, expected map[string]bool) {
+		should := should.New(t)
+
+		actual := reachingSymbols(symbols, []string{"main.main"}, wanted)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should only include reachable symbols reaching syscalls", nil,
+		map[string]bool{"main.main": true, "main.write": true, "main.exit": true})
+	assertThat("should only include symbols reaching the wanted syscalls", map[uint16]bool{231: true},
+		map[string]bool{"main.main": true, "main.exit": true})
+	assertThat("should include nothing when no wanted syscall is reachable", map[uint16]bool{0: true},
+		map[string]bool{})
+}
@@ -0,0 +1,50 @@
+package systract
+
+import (
+	"regexp"
+	"strings"
+)
+
+// functionReferenceRegex matches instructions taking the address of a symbol, e.g.
+// LEAQ main.main.func1·f(SB), or relocations to function values within object files,
+// e.g. R_PCREL:main.handler·f, capturing the symbol name without the function value suffix.
+const functionReferenceRegex string = "([a-zA-Z0-9_.\\/%()*\\[\\]-]+)(?:·f)?(?:\\+[0-9]+)?\\(SB\\)|R_[A-Z0-9_]+:([a-zA-Z0-9_.\\/%()*\\[\\]-]+)·f"
+
+var functionReference = regexp.MustCompile(functionReferenceRegex)
+
+// getFunctionReference returns the symbol whose address is taken by the instruction.
+// Not all of them are functions, which is only known once all symbols were parsed.
+func getFunctionReference(assemblyLine string) (string, bool) {
+	if !strings.Contains(assemblyLine, "(SB)") && !strings.Contains(assemblyLine, funcValueSuffix) {
+		return "", false
+	}
+
+	captures := functionReference.FindStringSubmatch(assemblyLine)
+	if captures == nil {
+		return "", false
+	}
+
+	if captures[1] != "" {
+		return captures[1], true
+	}
+
+	return captures[2], true
+}
+
+// linkFunctionReferences makes the functions whose address is taken by a symbol callable
+// from it. Function values, closures and goroutines are called indirectly, through a
+// register or runtime.newproc, so taking their address is considered as calling them.
+func linkFunctionReferences(symbols map[string]symbolDefinition) {
+	for name, s := range symbols {
+		if len(s.references) == 0 {
+			continue
+		}
+
+		for _, r := range s.references {
+			if _, found := symbols[r]; found && r != name {
+				s.subCalls = append(s.subCalls, r)
+			}
+		}
+		symbols[name] = s
+	}
+}
@@ -0,0 +1,62 @@
+package systract
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestExtract_E2E_FunctionValues(t *testing.T) {
+	should := should.New(t)
+	filePath, _ := filepath.Abs("../../test/function-values.dump")
+
+	actual, err := Extract(NewDumpReader(filePath))
+
+	should.NotError(err, "should extract syscalls")
+	should.HaveSameItems(keys([]SystemCall{{ID: 1, Name: "write"}, {ID: 39, Name: "getpid"}}), keys(actual),
+		"should reach goroutines and functions passed as values")
+}
+
+func TestGetFunctionReference(t *testing.T) {
+	assertThat := func(assumption, line, expected string, expectedFound bool) {
+		should := should.New(t)
+
+		actual, found := getFunctionReference(line)
+
+		should.BeEqual(expectedFound, found, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should capture function values",
+		"  main.go:10	0x495e40	488d05b9a60400	LEAQ main.main.func1·f(SB), AX", "main.main.func1", true)
+	assertThat("should capture method values",
+		"  main.go:10	0x495e40	488d05b9a60400	LEAQ os.(*File).Close·f(SB), CX", "os.(*File).Close", true)
+	assertThat("should capture function addresses",
+		"  arena.go:912	0x41a9ad	488d0d8c540600	LEAQ runtime.mmap.func1(SB), CX", "runtime.mmap.func1", true)
+	assertThat("should capture function values on 32 bits",
+		"  main.go:10	0x8049000	8d05b9a60400	LEAL main.handler·f(SB), AX", "main.handler", true)
+	assertThat("should capture relocations in object files",
+		"  systrac.go:138	0x68e1	488d0d00000000	LEAQ 0(IP), CX	[3:7]R_PCREL:%22%22.processDump.func1·f",
+		"%22%22.processDump.func1", true)
+	assertThat("should ignore relocations to data",
+		"  autogenerated:1	0x7c3a	0fb60500000000	MOVZX 0(IP), AX	[3:7]R_PCREL:%22%22.initdone·", "", false)
+	assertThat("should ignore unresolved addresses",
+		"  cpu.go:250	0x40122a	488d0544352e00	LEAQ 0x2e3544(IP), AX", "", false)
+	assertThat("should ignore register operands",
+		"  main.go:12	0x495e47	488b4818	MOVQ 0x18(AX), CX", "", false)
+}
+
+func TestLinkFunctionReferences(t *testing.T) {
+	should := should.New(t)
+	symbols := map[string]symbolDefinition{
+		"main.main":       {subCalls: []string{"runtime.newproc"}, references: []string{"main.main.func1", "main.config"}},
+		"main.main.func1": {syscallIDs: []uint16{39}, references: []string{"main.main.func1"}},
+	}
+
+	linkFunctionReferences(symbols)
+
+	should.BeEqual([]string{"runtime.newproc", "main.main.func1"}, symbols["main.main"].subCalls,
+		"should make referenced functions callable, ignoring other symbols")
+	should.BeEqual(0, len(symbols["main.main.func1"].subCalls), "should ignore references to itself")
+}
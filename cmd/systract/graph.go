@@ -0,0 +1,295 @@
+package systract
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// graphMagic starts all symbol graph files.
+	graphMagic string = "gosystract-sgx\n"
+	// graphVersion is the version of the symbol graph format, to be increased
+	// whenever symbols are encoded differently.
+	graphVersion uint64 = 1
+	// minSymbolSize is the size of the smallest symbol record, made of an empty
+	// name followed by the empty lists and maps of its definition.
+	minSymbolSize int = 10
+)
+
+// graphHeader holds the metadata of the source a symbol graph was saved from.
+type graphHeader struct {
+	arch      string
+	goVersion string
+	modules   []string
+	// sourceID is the go build id, or the SHA-256, of the file the graph was saved from.
+	sourceID string
+	symbols  int
+}
+
+// SaveGraph parses the source and saves its symbol graph into filePath, so that it can
+// be analysed again through a GraphReader without disassembling or parsing the source.
+// The graph holds all symbols parsed alongside their calls, syscalls and sites, and the
+// architecture, go version and modules of the source.
+func SaveGraph(source SourceReader, filePath string) error {
+	filePath, err := sanitiseFileName(filePath)
+	if err != nil {
+		return err
+	}
+
+	arch, symbols, err := parseSource(source)
+	if err != nil {
+		return err
+	}
+
+	header := graphHeader{
+		arch:      arch.name,
+		goVersion: getGoVersion(source),
+		modules:   getModules(source),
+		symbols:   len(symbols),
+	}
+	if s, ok := source.(fileSource); ok {
+		if path, err := s.file(); err == nil {
+			header.sourceID, _ = getFileID(path)
+		}
+	}
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return errors.Wrap(err, "could not create symbol graph")
+	}
+	err = writeGraph(f, header, symbols)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(filePath)
+		return errors.Wrap(err, "could not write symbol graph")
+	}
+
+	return nil
+}
+
+// writeGraph writes the magic and format version, followed by the length-prefixed header
+// and the symbols sorted by name, so that the same symbols always produce the same file.
+func writeGraph(w io.Writer, header graphHeader, symbols map[string]symbolDefinition) error {
+	names := make([]string, 0, len(symbols))
+	for name := range symbols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var e recordEncoder
+	e.buf = append(e.buf, graphMagic...)
+	e.uint(graphVersion)
+
+	var h recordEncoder
+	h.string(header.arch)
+	h.string(header.goVersion)
+	h.strings(header.modules)
+	h.string(header.sourceID)
+	h.uint(uint64(len(names)))
+	e.uint(uint64(len(h.buf)))
+	e.buf = append(e.buf, h.buf...)
+
+	writer := bufio.NewWriter(w)
+	if _, err := writer.Write(e.buf); err != nil {
+		return err
+	}
+	for _, name := range names {
+		e.buf = e.buf[:0]
+		e.string(name)
+		encodeSymbol(&e, symbols[name])
+		if _, err := writer.Write(e.buf); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
+
+// readGraphHeader reads the header of a symbol graph, leaving the reader at its first symbol.
+func readGraphHeader(reader *bufio.Reader) (graphHeader, error) {
+	magic := make([]byte, len(graphMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil || string(magic) != graphMagic {
+		return graphHeader{}, errors.New("not a symbol graph file")
+	}
+
+	version, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return graphHeader{}, errCorruptRecord
+	}
+	if version != graphVersion {
+		return graphHeader{}, fmt.Errorf("unsupported symbol graph version: %d", version)
+	}
+
+	size, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return graphHeader{}, errCorruptRecord
+	}
+	// the size is read through a limit, so that corrupt sizes fail once the data ends
+	// instead of being allocated upfront.
+	buf, err := ioutil.ReadAll(io.LimitReader(reader, int64(size)))
+	if err != nil || uint64(len(buf)) != size {
+		return graphHeader{}, errCorruptRecord
+	}
+
+	d := recordDecoder{buf: buf}
+	header := graphHeader{
+		arch:      d.string(),
+		goVersion: d.string(),
+		modules:   d.strings(),
+		sourceID:  d.string(),
+		symbols:   int(d.uint()),
+	}
+
+	return header, d.err
+}
+
+// readGraph reads the header and all symbols of a symbol graph.
+func readGraph(r io.Reader) (graphHeader, map[string]symbolDefinition, error) {
+	reader := bufio.NewReader(r)
+	header, err := readGraphHeader(reader)
+	if err != nil {
+		return header, nil, err
+	}
+
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return header, nil, err
+	}
+
+	// the number of symbols is checked against the data read before
+	// allocating for them, as the header may be corrupt.
+	if header.symbols < 0 || header.symbols > len(data)/minSymbolSize {
+		return header, nil, errCorruptRecord
+	}
+
+	d := recordDecoder{buf: data}
+	symbols := make(map[string]symbolDefinition, header.symbols)
+	for i := 0; i < header.symbols; i++ {
+		name := d.string()
+		s, err := decodeSymbol(&d)
+		if err != nil {
+			return header, nil, err
+		}
+		symbols[name] = s
+	}
+	if len(d.buf) > 0 {
+		return header, nil, errCorruptRecord
+	}
+
+	return header, symbols, nil
+}
+
+// GraphReader represents a reader of symbol graphs saved by SaveGraph. Analysing it
+// loads the symbols saved instead of disassembling and parsing the original source.
+type GraphReader struct {
+	filePath string
+}
+
+// NewGraphReader initialises a new GraphReader
+func NewGraphReader(filePath string) *GraphReader {
+	return &GraphReader{filePath}
+}
+
+// GetReader returns a io.Reader based of the filePath
+func (g *GraphReader) GetReader() (io.ReadCloser, error) {
+	filePath, err := sanitiseFileName(g.filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !fileExists(filePath) {
+		return nil, errors.New("file does not exist or permission denied")
+	}
+
+	/* #nosec filePath is pre-processed by sanitiseFileName */
+	return os.Open(filePath)
+}
+
+// header returns the header of the symbol graph.
+func (g *GraphReader) header() (graphHeader, error) {
+	reader, err := g.GetReader()
+	if err != nil {
+		return graphHeader{}, err
+	}
+	defer reader.Close()
+
+	return readGraphHeader(bufio.NewReader(reader))
+}
+
+// symbols returns the architecture and the symbols of the symbol graph.
+func (g *GraphReader) symbols() (*archSpec, map[string]symbolDefinition, error) {
+	reader, err := g.GetReader()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer reader.Close()
+
+	header, symbols, err := readGraph(reader)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid symbol graph")
+	}
+
+	arch, found := archs[header.arch]
+	if !found {
+		return nil, nil, fmt.Errorf("unsupported architecture: %s", header.arch)
+	}
+
+	return arch, symbols, nil
+}
+
+// arch returns the architecture of the source the graph was saved from
+func (g *GraphReader) arch() (string, error) {
+	header, err := g.header()
+	return header.arch, err
+}
+
+// goVersion returns the go version of the source the graph was saved from
+func (g *GraphReader) goVersion() (string, error) {
+	header, err := g.header()
+	if err == nil && header.goVersion == "" {
+		err = errors.New("go version not recorded in symbol graph")
+	}
+	return header.goVersion, err
+}
+
+// modules returns the modules of the source the graph was saved from
+func (g *GraphReader) modules() ([]string, error) {
+	header, err := g.header()
+	if err == nil && len(header.modules) == 0 {
+		err = errors.New("modules not recorded in symbol graph")
+	}
+	return header.modules, err
+}
+
+// file returns the path of the symbol graph file
+func (g *GraphReader) file() (string, error) {
+	return sanitiseFileName(g.filePath)
+}
+
+// IsGraphFile checks whether the file contains a symbol graph saved by SaveGraph.
+func IsGraphFile(filePath string) bool {
+	filePath, err := sanitiseFileName(filePath)
+	if err != nil {
+		return false
+	}
+
+	/* #nosec filePath is pre-processed by sanitiseFileName */
+	f, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	magic := make([]byte, len(graphMagic))
+	_, err = io.ReadFull(f, magic)
+	return err == nil && bytes.Equal(magic, []byte(graphMagic))
+}
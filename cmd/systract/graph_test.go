@@ -0,0 +1,131 @@
+package systract
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestAnalyse_GraphReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gosystract-graph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	assertThat := func(assumption string, source SourceReader) {
+		should := should.New(t)
+		expected, err := Analyse(source)
+		should.NotError(err, assumption)
+
+		graphFile := filepath.Join(dir, "app.sgx")
+		should.NotError(SaveGraph(source, graphFile), assumption)
+		actual, err := Analyse(NewGraphReader(graphFile))
+
+		should.NotError(err, assumption)
+		should.BeEqual(expected, actual, assumption)
+		should.BeTrue(IsGraphFile(graphFile), assumption)
+	}
+
+	assertThat("should find the same syscalls as the dump", NewDumpReader("../../test/go1.21-syscalls.dump"))
+	assertThat("should resolve syscall numbers passed to wrappers", NewDumpReader("../../test/param-wrappers.dump"))
+	assertThat("should link methods of types converted into interfaces", NewDumpReader("../../test/interface-calls.dump"))
+	assertThat("should report unresolved syscall sites and calls", NewDumpReader("../../test/unresolved.dump"))
+	assertThat("should keep the architecture", NewDumpReader("../../test/arm64-single-syscall.dump"))
+	assertThat("should keep the go version and modules of executables", NewELFReader("../../test/simple-app"))
+	assertThat("should save the symbols reachable from bounded sources",
+		NewBoundedSource(NewELFReader("../../test/simple-app"), dir))
+}
+
+func TestWhy_GraphReader(t *testing.T) {
+	should := should.New(t)
+	dir, err := ioutil.TempDir("", "gosystract-graph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	graphFile := filepath.Join(dir, "app.sgx")
+	should.NotError(SaveGraph(NewDumpReader("../../test/single-syscall.dump"), graphFile), "should save graph")
+
+	actual, err := Why(NewGraphReader(graphFile), "exit_group")
+
+	should.NotError(err, "should explain syscalls of symbol graphs")
+	should.BeEqual([]CallChain{{"main.main"}}, actual, "should explain syscalls of symbol graphs")
+}
+
+func TestWriteGraph_Deterministic(t *testing.T) {
+	should := should.New(t)
+	_, symbols, err := parseSource(NewDumpReader("../../test/go1.21-syscalls.dump"))
+	should.NotError(err, "should parse source")
+
+	var first, second bytes.Buffer
+	header := graphHeader{arch: "amd64", goVersion: "go1.21.0", modules: []string{"example.com/app"}, symbols: len(symbols)}
+	should.NotError(writeGraph(&first, header, symbols), "should write graph")
+	should.NotError(writeGraph(&second, header, symbols), "should write graph")
+
+	should.BeTrue(bytes.Equal(first.Bytes(), second.Bytes()), "should write the same symbols alike")
+
+	actualHeader, actualSymbols, err := readGraph(bytes.NewReader(first.Bytes()))
+	should.NotError(err, "should read graph")
+	should.BeEqual(header, actualHeader, "should read the header written")
+
+	var reloaded bytes.Buffer
+	should.NotError(writeGraph(&reloaded, actualHeader, actualSymbols), "should write graph")
+	should.BeTrue(bytes.Equal(first.Bytes(), reloaded.Bytes()), "should read the symbols written")
+}
+
+func TestReadGraph_Errors(t *testing.T) {
+	assertThat := func(assumption string, data []byte) {
+		should := should.New(t)
+
+		_, _, err := readGraph(bytes.NewReader(data))
+
+		should.Error(err, assumption)
+	}
+
+	var graph bytes.Buffer
+	symbols := map[string]symbolDefinition{"main.main": {syscallIDs: []uint16{231}}}
+	if err := writeGraph(&graph, graphHeader{arch: "amd64", symbols: 1}, symbols); err != nil {
+		t.Fatal(err)
+	}
+	data := graph.Bytes()
+
+	var header, oversized recordEncoder
+	header.string("amd64")
+	header.string("")
+	header.strings(nil)
+	header.string("")
+	header.uint(1 << 40)
+	oversized.buf = append(oversized.buf, graphMagic...)
+	oversized.uint(graphVersion)
+	oversized.uint(uint64(len(header.buf)))
+	oversized.buf = append(oversized.buf, header.buf...)
+
+	hugeHeader := append([]byte(graphMagic), byte(graphVersion))
+	hugeHeader = append(hugeHeader, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01)
+	hugeHeader = append(hugeHeader, header.buf...)
+
+	unsupported := append([]byte(graphMagic), 2)
+	unsupported = append(unsupported, data[len(graphMagic)+1:]...)
+
+	assertThat("should fail on files which are not symbol graphs", []byte("TEXT main.main(SB) /app/main.go\n"))
+	assertThat("should fail on unsupported versions", unsupported)
+	assertThat("should fail on truncated headers", data[:len(graphMagic)+3])
+	assertThat("should fail on header sizes larger than the data", hugeHeader)
+	assertThat("should fail on truncated symbols", data[:len(data)-2])
+	assertThat("should fail on symbol counts larger than the data", oversized.buf)
+	assertThat("should fail on trailing data", append(append([]byte{}, data...), 0))
+}
+
+func TestIsGraphFile(t *testing.T) {
+	should := should.New(t)
+
+	should.BeFalse(IsGraphFile("../../test/single-syscall.dump"), "should not consider dump files as symbol graphs")
+	should.BeFalse(IsGraphFile("../../test/simple-app"), "should not consider executables as symbol graphs")
+	should.BeFalse(IsGraphFile("../../test/non-existent"), "should not consider missing files as symbol graphs")
+}
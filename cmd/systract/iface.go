@@ -0,0 +1,92 @@
+package systract
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// typeReferenceRegex matches references to itabs, e.g. go:itab.*os.File,io.Writer(SB),
+// and to type descriptors, e.g. type:*os.File(SB), capturing the concrete type.
+const typeReferenceRegex string = "\\bgo[.:]itab\\.(\\*?[a-zA-Z0-9_.\\/%-]+),|\\btype[.:](\\*?[a-zA-Z0-9_\\/%][a-zA-Z0-9_.\\/%-]*)(?:\\+[0-9]+)?\\(SB\\)"
+
+var typeReference = regexp.MustCompile(typeReferenceRegex)
+
+// getTypeReference returns the concrete type referenced by the instruction, when
+// converting it into an interface, or otherwise handing its type descriptor around.
+func getTypeReference(assemblyLine string) (string, bool) {
+	if !strings.Contains(assemblyLine, "itab.") && !strings.Contains(assemblyLine, "type") {
+		return "", false
+	}
+
+	captures := typeReference.FindStringSubmatch(assemblyLine)
+	if captures == nil {
+		return "", false
+	}
+
+	if captures[1] != "" {
+		return captures[1], true
+	}
+
+	return captures[2], true
+}
+
+// linkTypeMethods makes the methods of all concrete types referenced by a symbol callable
+// from it. Once a type is converted into an interface its methods can be called dynamically,
+// so this conservatively considers all of them reachable.
+func linkTypeMethods(symbols map[string]symbolDefinition) {
+	methods := make(map[string][]string)
+	for name := range symbols {
+		if receiver, ok := receiverType(name); ok {
+			methods[receiver] = append(methods[receiver], name)
+		}
+	}
+	for _, names := range methods {
+		sort.Strings(names)
+	}
+
+	for name, s := range symbols {
+		if len(s.types) == 0 {
+			continue
+		}
+
+		for _, t := range s.types {
+			s.subCalls = append(s.subCalls, methods[strings.TrimPrefix(t, "*")]...)
+		}
+		symbols[name] = s
+	}
+}
+
+// receiverType returns the receiver type of a method symbol, without pointer
+// indirection, e.g. os.File for both os.(*File).Write and os.File.Name.
+func receiverType(symbol string) (string, bool) {
+	pkg := packagePath(symbol)
+	if len(pkg) >= len(symbol) {
+		return "", false
+	}
+
+	rest := symbol[len(pkg)+1:]
+	if strings.HasPrefix(rest, "(*") {
+		end := strings.Index(rest, ").")
+		if end < 0 {
+			return "", false
+		}
+		return pkg + "." + trimTypeArgs(rest[2:end]), true
+	}
+
+	parts := strings.SplitN(rest, ".", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+
+	return pkg + "." + trimTypeArgs(parts[0]), true
+}
+
+// trimTypeArgs removes type arguments from generic type names.
+func trimTypeArgs(typeName string) string {
+	if i := strings.Index(typeName, "["); i >= 0 {
+		return typeName[:i]
+	}
+
+	return typeName
+}
@@ -0,0 +1,86 @@
+package systract
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestExtract_E2E_InterfaceCalls(t *testing.T) {
+	should := should.New(t)
+	filePath, _ := filepath.Abs("../../test/interface-calls.dump")
+
+	actual, err := Extract(NewDumpReader(filePath))
+
+	should.NotError(err, "should extract syscalls")
+	should.HaveSameItems(keys([]SystemCall{{ID: 1, Name: "write"}, {ID: 39, Name: "getpid"}}), keys(actual),
+		"should reach methods of types converted into interfaces")
+}
+
+func TestGetTypeReference(t *testing.T) {
+	assertThat := func(assumption, line, expected string, expectedFound bool) {
+		should := should.New(t)
+
+		actual, found := getTypeReference(line)
+
+		should.BeEqual(expectedFound, found, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should capture concrete type of itabs",
+		"  type.go:913	0x45acc2	488d0d77060800	LEAQ go.itab.*internal/reflectlite.rtype,internal/reflectlite.Type(SB), CX",
+		"*internal/reflectlite.rtype", true)
+	assertThat("should capture concrete type of itabs using newer naming",
+		"  main.go:12	0x495e40	488d05b9a60400	LEAQ go:itab.*os.File,io.Writer(SB), AX", "*os.File", true)
+	assertThat("should capture value types of itabs",
+		"  main.go:12	0x495e40	488d05b9a60400	LEAQ go:itab.syscall.Errno,error(SB), AX", "syscall.Errno", true)
+	assertThat("should capture type descriptors",
+		"  main.go:13	0x495e4d	488d05acbc0300	LEAQ type:*debug/elf.Section(SB), AX", "*debug/elf.Section", true)
+	assertThat("should capture type descriptors using older naming",
+		"  main.go:13	0x495e4d	488d05acbc0300	LEAQ type.main.logger(SB), AX", "main.logger", true)
+	assertThat("should capture type descriptors with offsets",
+		"  main.go:13	0x495e4d	488d05acbc0300	LEAQ type:main.logger+8(SB), AX", "main.logger", true)
+	assertThat("should ignore type functions",
+		"  alg.go:13	0x495e4d	e8a7ffffff	CALL type..eq.main.T(SB)", "", false)
+	assertThat("should ignore runtime.types",
+		"  type.go:13	0x495e4d	488d05acbc0300	LEAQ runtime.types(SB), AX", "", false)
+	assertThat("should ignore unresolved addresses",
+		"  main.go:13	0x495e4d	488d05acbc0300	LEAQ 0x2cddb2(IP), AX", "", false)
+}
+
+func TestReceiverType(t *testing.T) {
+	assertThat := func(assumption, symbol, expected string, expectedOk bool) {
+		should := should.New(t)
+
+		actual, ok := receiverType(symbol)
+
+		should.BeEqual(expectedOk, ok, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should return pointer receivers", "os.(*File).Write", "os.File", true)
+	assertThat("should return value receivers", "syscall.Errno.Error", "syscall.Errno", true)
+	assertThat("should return receivers with package paths", "github.com/pkg/errors.(*fundamental).Error",
+		"github.com/pkg/errors.fundamental", true)
+	assertThat("should return generic receivers", "sync/atomic.(*Pointer[go.shape.int]).Load", "sync/atomic.Pointer", true)
+	assertThat("should return false for functions", "syscall.Syscall", "", false)
+}
+
+func TestLinkTypeMethods(t *testing.T) {
+	should := should.New(t)
+	symbols := map[string]symbolDefinition{
+		"main.main":          {subCalls: []string{"fmt.Println"}, types: []string{"*os.File"}},
+		"os.(*File).Write":   {subCalls: []string{"syscall.Write"}},
+		"os.(*File).Read":    {subCalls: []string{"syscall.Read"}},
+		"os.File.Name":       {subCalls: []string{"runtime.concatstrings"}},
+		"os.(*Process).Kill": {subCalls: []string{"syscall.Kill"}},
+	}
+
+	linkTypeMethods(symbols)
+
+	should.BeEqual([]string{"fmt.Println", "os.(*File).Read", "os.(*File).Write", "os.File.Name"},
+		symbols["main.main"].subCalls, "should make all methods of the type callable")
+	should.BeEqual([]string{"syscall.Kill"}, symbols["os.(*Process).Kill"].subCalls,
+		"should not change symbols without type references")
+}
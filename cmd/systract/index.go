@@ -0,0 +1,346 @@
+package systract
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// indexBuckets is the number of hash buckets of the symbol index,
+	// which is all the index holds in memory.
+	indexBuckets int = 1 << 16
+	// recordHeaderSize is the size of the fixed part of records, made of the offset of
+	// the previous record of the same bucket and the length of the rest of the record.
+	recordHeaderSize int = 12
+	// addressPageBits sets the size of the address pages symbols are looked up by.
+	addressPageBits uint = 12
+)
+
+// recordKind tells what a record of the symbol index holds.
+type recordKind byte
+
+const (
+	// symbolRecord holds a TEXT symbol, alongside its definition when worth keeping.
+	symbolRecord recordKind = iota + 1
+	// methodRecord holds the name of a method, keyed by its receiver type.
+	methodRecord
+	// addressRecord holds the address range and name of a TEXT symbol, keyed
+	// by each of the address pages it spans.
+	addressRecord
+	// walkedRecord holds the syscall set and parameters of a symbol whose component
+	// has been walked, keyed by the symbol.
+	walkedRecord
+	// syscallSetRecord holds the syscall set of a component, addressed by its offset
+	// instead of a key, so that members and callers can share it.
+	syscallSetRecord
+)
+
+// symbolIndex is an append-only store of symbol definitions backed by a temporary file.
+// Records are addressable by key through a fixed number of hash buckets, each holding
+// the offset of its last record, which in turn points to the previous record of the
+// bucket. Memory usage is therefore independent of the number of symbols stored.
+type symbolIndex struct {
+	file    *os.File
+	writer  *bufio.Writer
+	size    int64
+	buckets []int64
+	encoder recordEncoder
+}
+
+// newSymbolIndex creates a symbol index in a temporary file within dir, which
+// defaults to the directory for temporary files when empty. The file is removed
+// once created, so that it is freed when the index is closed or the process ends.
+func newSymbolIndex(dir string) (*symbolIndex, error) {
+	f, err := ioutil.TempFile(dir, "gosystract-*.idx")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create symbol index")
+	}
+	if err := os.Remove(f.Name()); err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "could not create symbol index")
+	}
+
+	return &symbolIndex{
+		file:    f,
+		writer:  bufio.NewWriter(f),
+		buckets: make([]int64, indexBuckets),
+	}, nil
+}
+
+// close releases the file of the index.
+func (x *symbolIndex) close() error {
+	return x.file.Close()
+}
+
+// addSymbol appends a symbol, at the position index of the dump, and the
+// method it defines if any. Only symbols worth keeping have their definition stored.
+func (x *symbolIndex) addSymbol(p parsedSymbol) error {
+	e := &x.encoder
+	e.buf = e.buf[:0]
+	e.uint(uint64(p.index))
+	if p.kept {
+		e.buf = append(e.buf, 1)
+		encodeSymbol(e, p.symbol)
+	} else {
+		e.buf = append(e.buf, 0)
+	}
+
+	if err := x.append(symbolRecord, p.name, e.buf); err != nil {
+		return err
+	}
+
+	if p.start != 0 && p.end >= p.start {
+		e.buf = e.buf[:0]
+		e.uint(p.start)
+		e.uint(p.end)
+		e.string(p.name)
+		for page := p.start >> addressPageBits; page <= p.end>>addressPageBits; page++ {
+			if err := x.append(addressRecord, addressPage(page), e.buf); err != nil {
+				return err
+			}
+		}
+	}
+
+	if receiver, ok := receiverType(p.name); ok && p.kept {
+		return x.append(methodRecord, receiver, []byte(p.name))
+	}
+
+	return nil
+}
+
+// append writes a record at the end of the index, chaining it to the bucket of its key.
+func (x *symbolIndex) append(kind recordKind, key string, payload []byte) error {
+	bucket := bucketOf(key)
+	offset, err := x.write(kind, key, x.buckets[bucket], payload)
+	if err != nil {
+		return err
+	}
+
+	// offsets are stored plus one, so that zero marks the end of the chain.
+	x.buckets[bucket] = offset + 1
+
+	return nil
+}
+
+// write writes a record at the end of the index, pointing to the previous record prev,
+// returning its offset.
+func (x *symbolIndex) write(kind recordKind, key string, prev int64, payload []byte) (int64, error) {
+	var header [recordHeaderSize]byte
+	var keyLength [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(keyLength[:], uint64(len(key)))
+	binary.LittleEndian.PutUint64(header[:8], uint64(prev))
+	binary.LittleEndian.PutUint32(header[8:], uint32(1+n+len(key)+len(payload)))
+
+	offset := x.size
+	for _, b := range [][]byte{header[:], {byte(kind)}, keyLength[:n], []byte(key), payload} {
+		written, err := x.writer.Write(b)
+		x.size += int64(written)
+		if err != nil {
+			return 0, errors.Wrap(err, "could not write to symbol index")
+		}
+	}
+
+	return offset, nil
+}
+
+// records calls fn with the payload of each record of the kind for the key, from the
+// last appended to the first, until it returns false.
+func (x *symbolIndex) records(kind recordKind, key string, fn func(payload []byte) bool) error {
+	for next := x.buckets[bucketOf(key)]; next != 0; {
+		prev, record, err := x.read(next - 1)
+		if err != nil {
+			return err
+		}
+		next = prev
+
+		d := recordDecoder{buf: record[1:]}
+		if recordKind(record[0]) != kind || d.string() != key {
+			continue
+		}
+		if d.err != nil {
+			return d.err
+		}
+		if !fn(d.buf) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// read returns the record at the offset, made of its kind, key and payload, alongside
+// the offset of the previous record of its bucket.
+func (x *symbolIndex) read(offset int64) (int64, []byte, error) {
+	if x.writer.Buffered() > 0 {
+		if err := x.writer.Flush(); err != nil {
+			return 0, nil, errors.Wrap(err, "could not write to symbol index")
+		}
+	}
+
+	var header [recordHeaderSize]byte
+	if _, err := x.file.ReadAt(header[:], offset); err != nil {
+		return 0, nil, errors.Wrap(err, "could not read symbol index")
+	}
+
+	record := make([]byte, binary.LittleEndian.Uint32(header[8:]))
+	if _, err := x.file.ReadAt(record, offset+int64(recordHeaderSize)); err != nil {
+		return 0, nil, errors.Wrap(err, "could not read symbol index")
+	}
+	if len(record) == 0 {
+		return 0, nil, errCorruptRecord
+	}
+
+	return int64(binary.LittleEndian.Uint64(header[:8])), record, nil
+}
+
+// symbol returns the last definition of a symbol worth keeping, and whether the
+// symbol was defined at all, even if its definition was not worth keeping.
+func (x *symbolIndex) symbol(name string) (symbol symbolDefinition, kept bool, text bool, err error) {
+	last := -1
+	var decodeErr error
+	err = x.records(symbolRecord, name, func(payload []byte) bool {
+		text = true
+
+		d := recordDecoder{buf: payload}
+		index := int(d.uint())
+		if len(d.buf) == 0 || d.buf[0] == 0 || index < last {
+			return true
+		}
+
+		d.buf = d.buf[1:]
+		symbol, decodeErr = decodeSymbol(&d)
+		kept, last = decodeErr == nil, index
+		return decodeErr == nil
+	})
+	if err == nil {
+		err = decodeErr
+	}
+
+	return
+}
+
+// methods returns the sorted names of the methods of the receiver type.
+func (x *symbolIndex) methods(receiver string) ([]string, error) {
+	unique := make(map[string]bool)
+	err := x.records(methodRecord, receiver, func(payload []byte) bool {
+		unique[string(payload)] = true
+		return true
+	})
+
+	methods := make([]string, 0, len(unique))
+	for name := range unique {
+		methods = append(methods, name)
+	}
+	sort.Strings(methods)
+
+	return methods, err
+}
+
+// symbolAt returns the name of the TEXT symbol containing the address, or an empty string
+// when there is none.
+func (x *symbolIndex) symbolAt(addr uint64) (string, error) {
+	var name string
+	err := x.records(addressRecord, addressPage(addr>>addressPageBits), func(payload []byte) bool {
+		d := recordDecoder{buf: payload}
+		start, end := d.uint(), d.uint()
+		if d.err == nil && start <= addr && addr <= end {
+			name = d.string()
+			return false
+		}
+		return true
+	})
+
+	return name, err
+}
+
+// addSyscallSet appends the syscall set of a component, returning the reference
+// it is read by, which is never zero.
+func (x *symbolIndex) addSyscallSet(ids []uint16) (int64, error) {
+	e := &x.encoder
+	e.buf = e.buf[:0]
+	e.uint(uint64(len(ids)))
+	for _, id := range ids {
+		e.uint(uint64(id))
+	}
+
+	offset, err := x.write(syscallSetRecord, "", 0, e.buf)
+	return offset + 1, err
+}
+
+// syscallSet returns the syscall set of the reference, or nil for the zero reference.
+func (x *symbolIndex) syscallSet(ref int64) ([]uint16, error) {
+	if ref == 0 {
+		return nil, nil
+	}
+
+	_, record, err := x.read(ref - 1)
+	if err != nil {
+		return nil, err
+	}
+	if recordKind(record[0]) != syscallSetRecord {
+		return nil, errCorruptRecord
+	}
+
+	d := recordDecoder{buf: record[1:]}
+	d.string()
+	ids := make([]uint16, d.count())
+	for i := range ids {
+		ids[i] = uint16(d.uint())
+	}
+
+	return ids, d.err
+}
+
+// addWalked appends the reference to the syscall set of a walked symbol, alongside the
+// parameters it takes syscall numbers in.
+func (x *symbolIndex) addWalked(name string, set int64, params []int) error {
+	e := &x.encoder
+	e.buf = e.buf[:0]
+	e.uint(uint64(set))
+	e.uint(uint64(len(params)))
+	for _, pos := range params {
+		e.int(int64(pos))
+	}
+
+	return x.append(walkedRecord, name, e.buf)
+}
+
+// walked returns the reference to the syscall set of the symbol and its parameters,
+// and whether the symbol has been walked at all.
+func (x *symbolIndex) walked(name string) (set int64, params []int, found bool, err error) {
+	var decodeErr error
+	err = x.records(walkedRecord, name, func(payload []byte) bool {
+		d := recordDecoder{buf: payload}
+		set = int64(d.uint())
+		if n := d.count(); n > 0 {
+			params = make([]int, n)
+			for i := range params {
+				params[i] = int(d.int())
+			}
+		}
+		found, decodeErr = true, d.err
+		return false
+	})
+	if err == nil {
+		err = decodeErr
+	}
+
+	return
+}
+
+func addressPage(page uint64) string {
+	return strconv.FormatUint(page, 16)
+}
+
+func bucketOf(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(indexBuckets))
+}
@@ -0,0 +1,92 @@
+package systract
+
+import (
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestSymbolIndex(t *testing.T) {
+	should := should.New(t)
+	index, err := newSymbolIndex("")
+	should.NotError(err, "should create index")
+	defer index.close()
+
+	first := symbolDefinition{syscallIDs: []uint16{0}, subCalls: []string{}, types: []string{}, references: []string{}}
+	last := symbolDefinition{syscallIDs: []uint16{231}, subCalls: []string{}, types: []string{}, references: []string{}}
+	method := symbolDefinition{syscallIDs: []uint16{1}, subCalls: []string{}, types: []string{}, references: []string{}}
+	for _, p := range []parsedSymbol{
+		{index: 2, name: "main.f", symbol: last, kept: true},
+		{index: 0, name: "main.f", symbol: first, kept: true},
+		{index: 3, name: "main.f", kept: false},
+		{index: 1, name: "main.empty", kept: false},
+		{index: 5, name: "os.(*File).Write", symbol: method, kept: true},
+		{index: 4, name: "os.File.Name", symbol: method, kept: true},
+		{index: 6, name: "os.File.Name", symbol: method, kept: true},
+		{index: 7, name: "os.(*File).unused", kept: false},
+	} {
+		should.NotError(index.addSymbol(p), "should add symbols")
+	}
+
+	symbol, kept, text, err := index.symbol("main.f")
+	should.NotError(err, "should read symbols")
+	should.BeEqual(last, symbol, "should return the last definition worth keeping")
+	should.BeTrue(kept && text, "should find symbols worth keeping")
+
+	_, kept, text, err = index.symbol("main.empty")
+	should.NotError(err, "should read symbols")
+	should.BeTrue(!kept && text, "should find text symbols not worth keeping")
+
+	_, kept, text, err = index.symbol("main.missing")
+	should.NotError(err, "should read symbols")
+	should.BeTrue(!kept && !text, "should not find missing symbols")
+
+	methods, err := index.methods("os.File")
+	should.NotError(err, "should read methods")
+	should.BeEqual([]string{"os.(*File).Write", "os.File.Name"}, methods, "should return unique methods sorted, bar those not worth keeping")
+
+	for _, p := range []parsedSymbol{
+		{index: 8, name: "runtime.duffzero", start: 0x453f00, end: 0x454010},
+		{index: 9, name: "main.g", start: 0x454020, end: 0x454040},
+	} {
+		should.NotError(index.addSymbol(p), "should add symbols")
+	}
+
+	for addr, expected := range map[uint64]string{
+		0x453f00: "runtime.duffzero", 0x453ffc: "runtime.duffzero", 0x454010: "runtime.duffzero",
+		0x454030: "main.g", 0x454018: "", 0x500000: "",
+	} {
+		actual, err := index.symbolAt(addr)
+		should.NotError(err, "should read addresses")
+		should.BeEqual(expected, actual, "should return the symbol containing the address, across pages")
+	}
+
+	set, err := index.addSyscallSet([]uint16{1, 231})
+	should.NotError(err, "should add syscall sets")
+	should.NotError(index.addWalked("main.f", set, []int{1}), "should add walked symbols")
+	should.NotError(index.addWalked("main.g", 0, nil), "should add walked symbols")
+
+	ref, params, walked, err := index.walked("main.f")
+	should.NotError(err, "should read walked symbols")
+	should.BeTrue(walked, "should find walked symbols")
+	should.BeEqual([]int{1}, params, "should return the parameters of walked symbols")
+	ids, err := index.syscallSet(ref)
+	should.NotError(err, "should read syscall sets")
+	should.BeEqual([]uint16{1, 231}, ids, "should return the syscall set of walked symbols")
+
+	ref, _, walked, err = index.walked("main.g")
+	should.NotError(err, "should read walked symbols")
+	should.BeTrue(walked && ref == 0, "should find walked symbols without syscalls")
+	ids, err = index.syscallSet(ref)
+	should.NotError(err, "should read syscall sets")
+	should.BeTrue(ids == nil, "should return no syscalls for the zero set")
+
+	_, _, walked, err = index.walked("runtime.duffzero")
+	should.NotError(err, "should read walked symbols")
+	should.BeTrue(!walked, "should not find symbols yet to be walked")
+
+	should.NotError(index.append(symbolRecord, "main.corrupt", []byte{0, 1, 0x80}), "should add records")
+	_, kept, _, err = index.symbol("main.corrupt")
+	should.Error(err, "should fail on corrupt symbol records")
+	should.BeTrue(!kept, "should not keep corrupt symbols")
+}
@@ -0,0 +1,86 @@
+package systract
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Lock represents the system calls an executable is allowed to make.
+type Lock struct {
+	Arch        string       `json:"arch"`
+	GoVersion   string       `json:"goVersion,omitempty"`
+	Binary      string       `json:"binary"`
+	SystemCalls []SystemCall `json:"systemCalls"`
+}
+
+// NewLock initialises a new Lock based on the result of the binary provided.
+// Syscall sites are left out, as they change whenever the code is moved.
+func NewLock(result *Result, binary string) *Lock {
+	syscalls := make([]SystemCall, len(result.SystemCalls))
+	for i, s := range result.SystemCalls {
+		syscalls[i] = SystemCall{ID: s.ID, Name: s.Name}
+	}
+	sort.SliceStable(syscalls, func(i, j int) bool {
+		return syscalls[i].Name < syscalls[j].Name
+	})
+
+	return &Lock{
+		Arch:        result.Arch,
+		GoVersion:   result.GoVersion,
+		Binary:      binary,
+		SystemCalls: syscalls,
+	}
+}
+
+// LoadLock reads a lockfile.
+func LoadLock(filePath string) (*Lock, error) {
+	filePath, err := sanitiseFileName(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !fileExists(filePath) {
+		return nil, errors.New("lockfile does not exist or permission denied")
+	}
+
+	/* #nosec filePath is pre-processed by sanitiseFileName */
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var lock Lock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, errors.Wrap(err, "invalid lockfile")
+	}
+
+	return &lock, nil
+}
+
+// SaveLock writes the lock into filePath, replacing any existing content.
+func SaveLock(filePath string, lock *Lock) error {
+	filePath, err := sanitiseFileName(filePath)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filePath, append(data, '\n'), os.FileMode(0644))
+}
+
+// Check returns the system calls in result which are not allowed by the lock.
+func (l *Lock) Check(result *Result) ([]SystemCall, error) {
+	if l.Arch != result.Arch {
+		return nil, fmt.Errorf("architecture mismatch: lockfile is %s but binary is %s", l.Arch, result.Arch)
+	}
+
+	return Diff(l.SystemCalls, result.SystemCalls).Added, nil
+}
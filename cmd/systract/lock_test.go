@@ -0,0 +1,84 @@
+package systract
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestNewLock(t *testing.T) {
+	should := should.New(t)
+	result := &Result{Arch: "amd64", GoVersion: "go1.13.4",
+		SystemCalls: []SystemCall{{ID: 231, Name: "exit_group"}, {ID: 0, Name: "read"}, {ID: 1, Name: "write"}}}
+
+	lock := NewLock(result, "bin/app")
+
+	should.BeEqual(&Lock{Arch: "amd64", GoVersion: "go1.13.4", Binary: "bin/app",
+		SystemCalls: []SystemCall{{ID: 231, Name: "exit_group"}, {ID: 0, Name: "read"}, {ID: 1, Name: "write"}}},
+		lock, "should record traceability information and sort syscalls by name")
+	should.BeEqual(SystemCall{ID: 231, Name: "exit_group"}, result.SystemCalls[0], "should not change result")
+}
+
+func TestSaveLoadLock(t *testing.T) {
+	should := should.New(t)
+	dir, err := ioutil.TempDir("", "lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "syscalls.lock")
+	expected := &Lock{Arch: "arm64", GoVersion: "go1.13.4", Binary: "bin/app",
+		SystemCalls: []SystemCall{{ID: 94, Name: "exit_group"}}}
+
+	err = SaveLock(filePath, expected)
+	should.NotError(err, "should save lockfile")
+
+	content, _ := ioutil.ReadFile(filePath)
+	should.BeEqual(`{
+  "arch": "arm64",
+  "goVersion": "go1.13.4",
+  "binary": "bin/app",
+  "systemCalls": [
+    {
+      "id": 94,
+      "name": "exit_group"
+    }
+  ]
+}
+`, string(content), "should save lockfile as indented json")
+
+	actual, err := LoadLock(filePath)
+	should.NotError(err, "should load lockfile")
+	should.BeEqual(expected, actual, "should load saved lockfile")
+
+	_, err = LoadLock(filepath.Join(dir, "missing.lock"))
+	should.Error(err, "should error when lockfile does not exist")
+
+	_, err = LoadLock("../../test/single-syscall.dump")
+	should.Error(err, "should error for invalid lockfiles")
+}
+
+func TestLockCheck(t *testing.T) {
+	lock := &Lock{Arch: "amd64", SystemCalls: []SystemCall{{ID: 0, Name: "read"}, {ID: 1, Name: "write"}}}
+
+	assertThat := func(assumption string, result *Result, expected []SystemCall, expectedErr bool) {
+		should := should.New(t)
+
+		actual, err := lock.Check(result)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should return no violations for subset of locked syscalls",
+		&Result{Arch: "amd64", SystemCalls: []SystemCall{{ID: 1, Name: "write"}}}, []SystemCall{}, false)
+	assertThat("should return syscalls not locked",
+		&Result{Arch: "amd64", SystemCalls: []SystemCall{{ID: 1, Name: "write"}, {ID: 101, Name: "ptrace"}}},
+		[]SystemCall{{ID: 101, Name: "ptrace"}}, false)
+	assertThat("should error for different architectures",
+		&Result{Arch: "arm64", SystemCalls: []SystemCall{}}, nil, true)
+}
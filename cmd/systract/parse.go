@@ -0,0 +1,162 @@
+package systract
+
+import (
+	"bufio"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// symbolText holds the header and instructions of a TEXT symbol of a dump,
+// alongside its position in the dump and the addresses of its first and last instructions.
+type symbolText struct {
+	index      int
+	header     string
+	name       string
+	lines      []string
+	start, end uint64
+}
+
+// parsedSymbol is a symbol parsed from its text, alongside its position in the dump,
+// its instruction addresses and whether it has anything worth keeping.
+type parsedSymbol struct {
+	index      int
+	name       string
+	symbol     symbolDefinition
+	kept       bool
+	start, end uint64
+}
+
+// parseDump parses the symbols of a go tool objdump output concurrently.
+func parseDump(reader io.Reader, arch *archSpec, convention callingConvention) map[string]symbolDefinition {
+	return parseDumpWith(reader, arch, convention, runtime.GOMAXPROCS(0))
+}
+
+// parseDumpWith parses the symbols of the dump on a pool of workers, merging them
+// into a single map. Symbols defined more than once are resolved to their last
+// definition worth keeping, as they would be when read in order.
+func parseDumpWith(reader io.Reader, arch *archSpec, convention callingConvention, workers int) map[string]symbolDefinition {
+	symbols := make(map[string]symbolDefinition)
+	textSymbols := make(map[string]bool)
+	indexes := make(map[string]int)
+	var addresses addressRanges
+
+	parseSymbols(reader, arch, convention, workers, func(p parsedSymbol) error {
+		textSymbols[p.name] = true
+		addresses.add(p.name, p.start, p.end)
+		if !p.kept {
+			return nil
+		}
+
+		if index, exists := indexes[p.name]; !exists || p.index > index {
+			indexes[p.name] = p.index
+			symbols[p.name] = p.symbol
+		}
+		return nil
+	})
+
+	addresses.sort()
+	for name, s := range symbols {
+		// lookups in memory cannot fail.
+		_ = linkAddressCalls(&s, addresses.symbolAt)
+		symbols[name] = s
+	}
+
+	linkTypeMethods(symbols)
+	linkFunctionReferences(symbols)
+	propagateSyscallArgs(symbols, arch)
+	linkUnresolvedCalls(symbols, textSymbols, arch)
+
+	return symbols
+}
+
+// parseSymbols splits the dump at TEXT boundaries as it is read, parsing its symbols
+// on a pool of workers. Each symbol parsed is handed to add, on the calling goroutine
+// and in no particular order, until it returns an error.
+func parseSymbols(reader io.Reader, arch *archSpec, convention callingConvention, workers int,
+	add func(parsedSymbol) error) error {
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	texts := make(chan symbolText, workers*4)
+	parsed := make(chan parsedSymbol, workers*4)
+
+	go func() {
+		splitSymbols(reader, func(text symbolText) {
+			texts <- text
+		})
+		close(texts)
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for text := range texts {
+				symbol, kept := parseSymbolText(text, arch, convention)
+				parsed <- parsedSymbol{index: text.index, name: text.name, symbol: symbol, kept: kept,
+					start: text.start, end: text.end}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(parsed)
+	}()
+
+	var err error
+	for p := range parsed {
+		if err == nil {
+			err = add(p)
+		}
+	}
+
+	return err
+}
+
+// splitSymbols reads the dump line by line, handing over each TEXT symbol
+// with its instructions, which end at the first empty line.
+func splitSymbols(reader io.Reader, emit func(symbolText)) {
+	scanner := bufio.NewScanner(reader)
+	index := 0
+	for scanner.Scan() {
+		header := scanner.Text()
+		symbolName, found := getSymbolName(header)
+		if !found {
+			continue
+		}
+
+		lines := make([]string, 0)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if isEndOfSymbol(line) {
+				break
+			}
+			lines = append(lines, line)
+		}
+
+		text := symbolText{index: index, header: header, name: symbolName, lines: lines}
+		if len(lines) > 0 {
+			text.start, text.end = getAddress(lines[0]), getAddress(lines[len(lines)-1])
+		}
+
+		emit(text)
+		index++
+	}
+}
+
+// parseSymbolText parses a symbol, returning whether it has anything worth keeping.
+func parseSymbolText(text symbolText, arch *archSpec, convention callingConvention) (symbolDefinition, bool) {
+	if strings.HasSuffix(text.name, abi0Suffix) {
+		convention = stackConvention
+	}
+
+	symbol := parseSymbol(text.name, getSymbolFile(text.header), text.lines, arch, convention)
+	return symbol, len(symbol.subCalls) > 0 || len(symbol.syscallIDs) > 0 || len(symbol.types) > 0 ||
+		len(symbol.references) > 0 || len(symbol.params) > 0 || len(symbol.unresolved) > 0 || symbol.indirectCalls
+}
@@ -0,0 +1,53 @@
+package systract
+
+// propagateSyscallArgs resolves the syscall numbers of parameterised wrappers, which
+// receive the syscall number as a parameter, e.g. func rawSyscall(trap uintptr), by
+// attributing the syscall numbers passed by their callers to the callers themselves.
+// Callers forwarding their own parameters become parameterised wrappers in turn, so
+// this repeats until no symbol changes.
+func propagateSyscallArgs(symbols map[string]symbolDefinition, arch *archSpec) {
+	params := func(target string) []int {
+		return symbols[target].params
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for name, symbol := range symbols {
+			if propagateCallArgs(&symbol, params, arch) {
+				symbols[name] = symbol
+				changed = true
+			}
+		}
+	}
+}
+
+// propagateCallArgs attributes the syscall numbers the symbol passes to parameterised
+// wrappers to the symbol, params returning the parameters of each callee. It returns
+// whether the symbol changed.
+func propagateCallArgs(symbol *symbolDefinition, params func(target string) []int, arch *archSpec) bool {
+	changed := false
+	for _, call := range symbol.calls {
+		for _, pos := range params(call.target) {
+			values, passed := call.args[pos]
+			if !passed {
+				continue
+			}
+
+			if site, ok := newSyscallSite(values, arch); ok {
+				changed = symbol.addSyscallSite(site, call.location) || changed
+			}
+		}
+	}
+
+	return changed
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
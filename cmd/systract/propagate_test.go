@@ -0,0 +1,76 @@
+package systract
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestExtract_E2E_ParameterisedWrappers(t *testing.T) {
+	should := should.New(t)
+	filePath, _ := filepath.Abs("../../test/param-wrappers.dump")
+
+	actual, err := Analyse(NewDumpReader(filePath))
+
+	should.NotError(err, "should analyse dump")
+	should.HaveSameItems(keys([]SystemCall{
+		{ID: 39, Name: "getpid"}, {ID: 231, Name: "exit_group"}, {ID: 72, Name: "fcntl"},
+	}), keys(actual.SystemCalls), "should propagate syscall numbers passed to wrappers")
+	should.BeEqual(map[string]Confidence{
+		"getpid":     ConfidenceHigh,
+		"exit_group": ConfidenceHigh,
+		"fcntl":      ConfidenceHigh,
+	}, actual.Confidence, "should keep the confidence of the syscall numbers passed")
+}
+
+func TestPropagateSyscallArgs(t *testing.T) {
+	assertThat := func(assumption string, symbols map[string]symbolDefinition, expected map[string][]uint16) {
+		should := should.New(t)
+
+		propagateSyscallArgs(symbols, archs["amd64"])
+
+		actual := make(map[string][]uint16)
+		for name, symbol := range symbols {
+			if len(symbol.syscallIDs) > 0 {
+				actual[name] = symbol.syscallIDs
+			}
+		}
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should attribute syscall numbers passed to wrappers to callers", map[string]symbolDefinition{
+		"main.main":    {calls: []callArgs{{target: "main.wrapper", args: map[int][]int64{1: {39}}}}},
+		"main.wrapper": {params: []int{1}},
+	}, map[string][]uint16{"main.main": {39}})
+
+	assertThat("should ignore arguments not used as syscall numbers", map[string]symbolDefinition{
+		"main.main":    {calls: []callArgs{{target: "main.wrapper", args: map[int][]int64{0: {39}}}}},
+		"main.wrapper": {params: []int{1}},
+	}, map[string][]uint16{})
+
+	assertThat("should follow parameters forwarded through several wrappers", map[string]symbolDefinition{
+		"main.main":  {calls: []callArgs{{target: "main.outer", args: map[int][]int64{0: {1, 39}}}}},
+		"main.outer": {calls: []callArgs{{target: "main.inner", args: map[int][]int64{2: {paramValue(0)}}}}},
+		"main.inner": {
+			params: []int{2},
+			calls:  []callArgs{{target: "main.inner", args: map[int][]int64{2: {paramValue(2)}}}},
+		},
+	}, map[string][]uint16{"main.main": {1, 39}})
+}
+
+func TestParamValue(t *testing.T) {
+	should := should.New(t)
+
+	for pos := 0; pos < maxForwardedArgs; pos++ {
+		actual, ok := paramPosition(paramValue(pos))
+
+		should.BeTrue(ok, "should represent parameters")
+		should.BeEqual(pos, actual, "should represent the position of parameters")
+	}
+
+	_, ok := paramPosition(paramValue(1) + 8)
+	should.BeFalse(ok, "should not represent parameters offset by additions")
+	_, ok = paramPosition(39)
+	should.BeFalse(ok, "should not represent constants")
+}
@@ -0,0 +1,98 @@
+package systract
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// LoadResult reads a result previously saved as json.
+func LoadResult(filePath string) (*Result, error) {
+	filePath, err := sanitiseFileName(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !fileExists(filePath) {
+		return nil, errors.New("file does not exist or permission denied")
+	}
+
+	/* #nosec filePath is pre-processed by sanitiseFileName */
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	result, err := decodeResult(f)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid result file")
+	}
+
+	return result, nil
+}
+
+// IsResultFile checks whether the file contains a result saved as json.
+func IsResultFile(filePath string) bool {
+	filePath, err := sanitiseFileName(filePath)
+	if err != nil {
+		return false
+	}
+
+	/* #nosec filePath is pre-processed by sanitiseFileName */
+	f, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	_, err = decodeResult(f)
+	return err == nil
+}
+
+// decodeResult reads a result saved as json. Sources which do not start with a json
+// object, such as executables, are rejected without being read any further. Other json
+// documents holding some of its fields, such as lockfiles or seccomp profiles, are told
+// apart by requiring the architecture and system calls, and rejecting fields results
+// do not have.
+func decodeResult(r io.Reader) (*Result, error) {
+	reader := bufio.NewReader(r)
+	if !startsWithObject(reader) {
+		return nil, errors.New("not a json object")
+	}
+
+	var result Result
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.SystemCalls == nil {
+		return nil, errors.New("missing system calls")
+	}
+	if result.Arch == "" {
+		return nil, errors.New("missing architecture")
+	}
+
+	return &result, nil
+}
+
+// startsWithObject checks whether the first byte after any leading whitespace opens a
+// json object, leaving that byte to be read.
+func startsWithObject(reader *bufio.Reader) bool {
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return false
+		}
+
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+
+		return b == '{' && reader.UnreadByte() == nil
+	}
+}
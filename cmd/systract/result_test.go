@@ -0,0 +1,92 @@
+package systract
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+// seccompProfileJSON is a seccomp profile, which shares no required field with results.
+const seccompProfileJSON string = `{"defaultAction":"SCMP_ACT_ERRNO","architectures":["SCMP_ARCH_X86_64"],` +
+	`"syscalls":[{"names":["write"],"action":"SCMP_ACT_ALLOW"}]}`
+
+func writeTempFile(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "result.*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+
+	return f.Name()
+}
+
+func TestLoadResult(t *testing.T) {
+	assertThat := func(assumption, filePath string, expected *Result, expectedErr bool) {
+		should := should.New(t)
+
+		actual, err := LoadResult(filePath)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	valid := writeTempFile(t, `{"arch":"arm64","systemCalls":[{"id":94,"name":"exit_group"}]}`)
+	defer os.Remove(valid)
+	profile := writeTempFile(t, seccompProfileJSON)
+	defer os.Remove(profile)
+
+	assertThat("should load saved results", valid,
+		&Result{Arch: "arm64", SystemCalls: []SystemCall{{ID: 94, Name: "exit_group"}}}, false)
+	assertThat("should error for files which are not results", "../../test/single-syscall.dump", nil, true)
+	assertThat("should error for seccomp profiles", profile, nil, true)
+	assertThat("should error when file does not exist", "/tmp/3216763872163876321", nil, true)
+}
+
+func TestIsResultFile(t *testing.T) {
+	assertThat := func(assumption, filePath string, expected bool) {
+		should := should.New(t)
+
+		actual := IsResultFile(filePath)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	valid := writeTempFile(t, `{"arch":"amd64","systemCalls":[]}`)
+	defer os.Remove(valid)
+	indented := writeTempFile(t, "\n  {\"arch\":\"amd64\",\"systemCalls\":[]}\n")
+	defer os.Remove(indented)
+	full := writeTempFile(t, `{"arch":"amd64","goVersion":"go1.21.0","systemCalls":[{"id":1,"name":"write",`+
+		`"sites":[{"symbol":"main.main","file":"main.go","line":5,"address":4198400}]}],`+
+		`"packages":[{"package":"main","systemCalls":[{"id":1,"name":"write"}]}],"runtimeRoots":["runtime.sigtramp"],`+
+		`"confidence":{"write":"high"}}`)
+	defer os.Remove(full)
+	profile := writeTempFile(t, seccompProfileJSON)
+	defer os.Remove(profile)
+	lock := writeTempFile(t, `{"arch":"amd64","binary":"app","systemCalls":[{"id":1,"name":"write"}]}`)
+	defer os.Remove(lock)
+	empty := writeTempFile(t, `{}`)
+	defer os.Remove(empty)
+	noArch := writeTempFile(t, `{"systemCalls":[]}`)
+	defer os.Remove(noArch)
+	array := writeTempFile(t, `[{"arch":"amd64","systemCalls":[]}]`)
+	defer os.Remove(array)
+
+	assertThat("should return true for saved results", valid, true)
+	assertThat("should return true for saved results with leading whitespace", indented, true)
+	assertThat("should return true for saved results with all fields", full, true)
+	assertThat("should return false for seccomp profiles", profile, false)
+	assertThat("should return false for lockfiles", lock, false)
+	assertThat("should return false for empty json objects", empty, false)
+	assertThat("should return false for results without architecture", noArch, false)
+	assertThat("should return false for json documents other than objects", array, false)
+	assertThat("should return false for dump files", "../../test/single-syscall.dump", false)
+	assertThat("should return false for executables", "../../test/simple-app", false)
+	assertThat("should return false when file does not exist", "/tmp/3216763872163876321", false)
+}
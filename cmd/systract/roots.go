@@ -0,0 +1,122 @@
+package systract
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// abi0Suffix is appended by newer go linkers to the names of assembly functions.
+const abi0Suffix string = ".abi0"
+
+// runtimeRoot is a symbol the go runtime starts executing without a call instruction
+// leading to it, e.g. being jumped into by the kernel or from assembly.
+type runtimeRoot struct {
+	symbol string
+	// archs restricts the root to the given architectures, all when empty.
+	archs []string
+	// since and until restrict the root to a range of go minor versions, e.g. 14 for go1.14.
+	// Zero means unbounded.
+	since, until int
+}
+
+// runtimeRoots is the catalogue of runtime roots, which are always considered
+// reachable when found in the source.
+var runtimeRoots = []runtimeRoot{
+	// process entry points, jumped into by the kernel.
+	{symbol: "_rt0_amd64_linux", archs: []string{"amd64"}},
+	{symbol: "_rt0_amd64", archs: []string{"amd64"}},
+	{symbol: "_rt0_arm64_linux", archs: []string{"arm64"}},
+	{symbol: "_rt0_386_linux", archs: []string{"386"}},
+	{symbol: "_rt0_386", archs: []string{"386"}},
+	{symbol: "_rt0_arm_linux", archs: []string{"arm"}},
+	{symbol: "_rt0_riscv64_linux", archs: []string{"riscv64"}},
+	{symbol: "_rt0_ppc64le_linux", archs: []string{"ppc64le"}},
+	{symbol: "_rt0_s390x_linux", archs: []string{"s390x"}},
+	{symbol: "runtime.rt0_go"},
+	{symbol: "runtime.main"},
+
+	// threads and goroutines, started through clone or jumped into from the scheduler.
+	{symbol: "runtime.mstart"},
+	{symbol: "runtime.mstart0", since: 17},
+	{symbol: "runtime.mstart1"},
+	{symbol: "runtime.goexit"},
+	{symbol: "runtime.mcall"},
+	{symbol: "runtime.morestack"},
+	{symbol: "runtime.templateThread"},
+
+	// signal handling, jumped into by the kernel.
+	{symbol: "runtime.sigtramp"},
+	{symbol: "runtime.cgoSigtramp"},
+	{symbol: "runtime.sigtrampgo"},
+	{symbol: "runtime.sigreturn"},
+	{symbol: "runtime.sigreturn__sigaction"},
+	{symbol: "runtime.sigpanic"},
+	{symbol: "runtime.asyncPreempt", since: 14},
+
+	// background goroutines started by the runtime.
+	{symbol: "runtime.sysmon"},
+	{symbol: "runtime.forcegchelper"},
+	{symbol: "runtime.bgsweep"},
+	{symbol: "runtime.bgscavenge"},
+	{symbol: "runtime.gcBgMarkWorker"},
+	{symbol: "runtime.timerproc", until: 13},
+	{symbol: "runtime.runfinq"},
+	{symbol: "runtime.runFinalizers"},
+	{symbol: "runtime.runCleanups"},
+}
+
+// findRuntimeRoots returns the runtime roots found amongst the symbols, for the
+// given architecture and go version, sorted by name. The go version is optional
+// and when empty all roots for the architecture are considered.
+func findRuntimeRoots(symbols map[string]symbolDefinition, arch, goVersion string) []string {
+	minor, hasVersion := goMinorVersion(goVersion)
+
+	roots := make([]string, 0)
+	for _, r := range runtimeRoots {
+		if len(r.archs) > 0 && !containsString(r.archs, arch) {
+			continue
+		}
+		if hasVersion && ((r.since > 0 && minor < r.since) || (r.until > 0 && minor > r.until)) {
+			continue
+		}
+
+		for _, name := range []string{r.symbol, r.symbol + abi0Suffix} {
+			if _, found := symbols[name]; found {
+				roots = append(roots, name)
+			}
+		}
+	}
+	sort.Strings(roots)
+
+	return roots
+}
+
+// goMinorVersion returns the minor version of go1.x versions, e.g. 13 for go1.13.4.
+func goMinorVersion(goVersion string) (int, bool) {
+	if !strings.HasPrefix(goVersion, "go1.") {
+		return 0, false
+	}
+
+	v := strings.TrimPrefix(goVersion, "go1.")
+	if i := strings.IndexFunc(v, func(r rune) bool { return r < '0' || r > '9' }); i >= 0 {
+		v = v[:i]
+	}
+
+	minor, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, false
+	}
+
+	return minor, true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,69 @@
+package systract
+
+import (
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestAnalyse_E2E_RuntimeRoots(t *testing.T) {
+	should := should.New(t)
+
+	actual, err := Analyse(NewELFReader("../../test/simple-app"))
+
+	should.NotError(err, "should analyse executable")
+	should.BeTrue(containsString(actual.RuntimeRoots, "runtime.sigtramp"), "should report runtime roots")
+	should.BeTrue(containsString(actual.RuntimeRoots, "_rt0_amd64_linux"), "should report process entry point")
+	should.BeTrue(containsString(syscallNames(actual.SystemCalls), "rt_sigreturn"),
+		"should find syscalls issued from signal handlers")
+	should.BeTrue(containsString(syscallNames(actual.SystemCalls), "sigaltstack"),
+		"should find syscalls issued when starting threads")
+}
+
+func TestFindRuntimeRoots(t *testing.T) {
+	symbols := map[string]symbolDefinition{
+		"main.main":               {},
+		"_rt0_amd64_linux":        {},
+		"_rt0_arm64_linux":        {},
+		"runtime.sigtramp.abi0":   {},
+		"runtime.timerproc":       {},
+		"runtime.asyncPreempt":    {},
+		"runtime.notARuntimeRoot": {},
+	}
+
+	assertThat := func(assumption, arch, goVersion string, expected []string) {
+		should := should.New(t)
+
+		actual := findRuntimeRoots(symbols, arch, goVersion)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should return roots for the architecture and go version", "amd64", "go1.13.4",
+		[]string{"_rt0_amd64_linux", "runtime.sigtramp.abi0", "runtime.timerproc"})
+	assertThat("should exclude roots of other go versions", "arm64", "go1.21.0",
+		[]string{"_rt0_arm64_linux", "runtime.asyncPreempt", "runtime.sigtramp.abi0"})
+	assertThat("should return all roots when go version is unknown", "amd64", "",
+		[]string{"_rt0_amd64_linux", "runtime.asyncPreempt", "runtime.sigtramp.abi0", "runtime.timerproc"})
+
+	should := should.New(t)
+	actual := findRuntimeRoots(map[string]symbolDefinition{"main.main": {}}, "amd64", "go1.13")
+	should.BeEqual([]string{}, actual, "should return empty when no roots are found")
+}
+
+func TestGoMinorVersion(t *testing.T) {
+	assertThat := func(assumption, goVersion string, expected int, expectedOk bool) {
+		should := should.New(t)
+
+		actual, ok := goMinorVersion(goVersion)
+
+		should.BeEqual(expectedOk, ok, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should parse patch versions", "go1.13.4", 13, true)
+	assertThat("should parse minor versions", "go1.21", 21, true)
+	assertThat("should parse pre-releases", "go1.22rc1", 22, true)
+	assertThat("should not parse devel versions", "devel go1.22-abc", 0, false)
+	assertThat("should not parse empty versions", "", 0, false)
+}
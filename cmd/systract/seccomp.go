@@ -0,0 +1,113 @@
+package systract
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Seccomp actions supported as the default action of a profile.
+const (
+	SeccompActErrno       string = "SCMP_ACT_ERRNO"
+	SeccompActKillProcess string = "SCMP_ACT_KILL_PROCESS"
+	SeccompActLog         string = "SCMP_ACT_LOG"
+	SeccompActAllow       string = "SCMP_ACT_ALLOW"
+
+	// defaultErrnoRet is the errno returned for blocked syscalls
+	// when none is set, which is EPERM.
+	defaultErrnoRet uint = 1
+)
+
+var seccompArchs = map[string]string{
+	"amd64":   "SCMP_ARCH_X86_64",
+	"arm64":   "SCMP_ARCH_AARCH64",
+	"386":     "SCMP_ARCH_X86",
+	"arm":     "SCMP_ARCH_ARM",
+	"riscv64": "SCMP_ARCH_RISCV64",
+	"ppc64le": "SCMP_ARCH_PPC64LE",
+	"s390x":   "SCMP_ARCH_S390X",
+}
+
+// SeccompProfile represents a Docker/OCI seccomp profile.
+type SeccompProfile struct {
+	DefaultAction   string           `json:"defaultAction"`
+	DefaultErrnoRet *uint            `json:"defaultErrnoRet,omitempty"`
+	Architectures   []string         `json:"architectures,omitempty"`
+	Syscalls        []SeccompSyscall `json:"syscalls"`
+}
+
+// SeccompSyscall represents a group of syscalls sharing the same action.
+type SeccompSyscall struct {
+	Names  []string `json:"names"`
+	Action string   `json:"action"`
+}
+
+// SeccompOptions defines how a seccomp profile is generated.
+type SeccompOptions struct {
+	// DefaultAction is applied to all syscalls not found, defaults to SCMP_ACT_ERRNO.
+	DefaultAction string
+
+	// ErrnoRet is the errno returned when DefaultAction is SCMP_ACT_ERRNO, defaults to EPERM.
+	ErrnoRet uint
+
+	// Architectures lists the seccomp architectures the profile applies to.
+	Architectures []string
+}
+
+// NewSeccompProfile generates a seccomp profile which allows only the syscalls provided.
+func NewSeccompProfile(syscalls []SystemCall, opts SeccompOptions) (*SeccompProfile, error) {
+	profile := &SeccompProfile{
+		DefaultAction: opts.DefaultAction,
+		Architectures: opts.Architectures,
+		Syscalls:      []SeccompSyscall{},
+	}
+
+	switch profile.DefaultAction {
+	case "":
+		profile.DefaultAction = SeccompActErrno
+		fallthrough
+	case SeccompActErrno:
+		errnoRet := opts.ErrnoRet
+		if errnoRet == 0 {
+			errnoRet = defaultErrnoRet
+		}
+		profile.DefaultErrnoRet = &errnoRet
+	case SeccompActKillProcess, SeccompActLog:
+	default:
+		return nil, fmt.Errorf("unsupported default action: %s", opts.DefaultAction)
+	}
+
+	names := syscallNames(syscalls)
+	if len(names) > 0 {
+		profile.Syscalls = append(profile.Syscalls, SeccompSyscall{
+			Names:  names,
+			Action: SeccompActAllow,
+		})
+	}
+
+	return profile, nil
+}
+
+// SeccompArch returns the seccomp architecture for the given GOARCH.
+func SeccompArch(goarch string) (string, error) {
+	arch, found := seccompArchs[goarch]
+	if !found {
+		return "", fmt.Errorf("unsupported architecture: %s", goarch)
+	}
+
+	return arch, nil
+}
+
+// syscallNames returns the sorted unique names of syscalls.
+func syscallNames(syscalls []SystemCall) []string {
+	unique := make(map[string]bool, len(syscalls))
+	names := make([]string, 0, len(syscalls))
+	for _, s := range syscalls {
+		if !unique[s.Name] {
+			unique[s.Name] = true
+			names = append(names, s.Name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,60 @@
+package systract
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestNewSeccompProfile(t *testing.T) {
+	assertThat := func(assumption string, syscalls []SystemCall, opts SeccompOptions, expected string, expectedErr bool) {
+		should := should.New(t)
+
+		profile, err := NewSeccompProfile(syscalls, opts)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		if !expectedErr {
+			actual, _ := json.Marshal(profile)
+			should.BeEqual(expected, string(actual), assumption)
+		}
+	}
+
+	syscalls := []SystemCall{{ID: 231, Name: "exit_group"}, {ID: 0, Name: "read"}, {ID: 231, Name: "exit_group"}}
+
+	assertThat("should default to SCMP_ACT_ERRNO returning EPERM", syscalls, SeccompOptions{},
+		`{"defaultAction":"SCMP_ACT_ERRNO","defaultErrnoRet":1,"syscalls":[{"names":["exit_group","read"],"action":"SCMP_ACT_ALLOW"}]}`, false)
+	assertThat("should support custom errno return values", syscalls,
+		SeccompOptions{DefaultAction: SeccompActErrno, ErrnoRet: 38},
+		`{"defaultAction":"SCMP_ACT_ERRNO","defaultErrnoRet":38,"syscalls":[{"names":["exit_group","read"],"action":"SCMP_ACT_ALLOW"}]}`, false)
+	assertThat("should not set errno for SCMP_ACT_KILL_PROCESS", syscalls,
+		SeccompOptions{DefaultAction: SeccompActKillProcess, ErrnoRet: 38},
+		`{"defaultAction":"SCMP_ACT_KILL_PROCESS","syscalls":[{"names":["exit_group","read"],"action":"SCMP_ACT_ALLOW"}]}`, false)
+	assertThat("should support SCMP_ACT_LOG and architectures", syscalls,
+		SeccompOptions{DefaultAction: SeccompActLog, Architectures: []string{"SCMP_ARCH_X86_64", "SCMP_ARCH_X86"}},
+		`{"defaultAction":"SCMP_ACT_LOG","architectures":["SCMP_ARCH_X86_64","SCMP_ARCH_X86"],"syscalls":[{"names":["exit_group","read"],"action":"SCMP_ACT_ALLOW"}]}`, false)
+	assertThat("should return empty syscalls when none are found", []SystemCall{},
+		SeccompOptions{DefaultAction: SeccompActKillProcess},
+		`{"defaultAction":"SCMP_ACT_KILL_PROCESS","syscalls":[]}`, false)
+	assertThat("should error for unsupported default actions", syscalls,
+		SeccompOptions{DefaultAction: SeccompActAllow}, "", true)
+}
+
+func TestSeccompArch(t *testing.T) {
+	assertThat := func(assumption, goarch, expected string, expectedErr bool) {
+		should := should.New(t)
+
+		actual, err := SeccompArch(goarch)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should map amd64", "amd64", "SCMP_ARCH_X86_64", false)
+	assertThat("should map arm64", "arm64", "SCMP_ARCH_AARCH64", false)
+	assertThat("should map 386", "386", "SCMP_ARCH_X86", false)
+	assertThat("should map ppc64le", "ppc64le", "SCMP_ARCH_PPC64LE", false)
+	assertThat("should error for unknown architectures", "mips", "", true)
+}
@@ -0,0 +1,56 @@
+package systract
+
+import (
+	"sort"
+	"strings"
+)
+
+// getSymbolFile returns the source file of a symbol definition, e.g. /app/main.go for TEXT main.main(SB) /app/main.go.
+func getSymbolFile(assemblyLine string) string {
+	i := strings.LastIndex(assemblyLine, "(SB) ")
+	if i < 0 {
+		return ""
+	}
+
+	return strings.TrimSpace(assemblyLine[i+len("(SB) "):])
+}
+
+// addSyscallSites sets the locations issuing each syscall within the reachable symbols.
+func addSyscallSites(syscalls []SystemCall, symbols map[string]symbolDefinition, reachable []string) {
+	sites := make(map[uint16][]Location)
+	for _, name := range reachable {
+		for id, locations := range symbols[name].sites {
+			sites[id] = append(sites[id], locations...)
+		}
+	}
+
+	for i := range syscalls {
+		if locations := sites[syscalls[i].ID]; len(locations) > 0 {
+			sortLocations(locations)
+			syscalls[i].Sites = locations
+		}
+	}
+}
+
+func sortLocations(locations []Location) {
+	sort.SliceStable(locations, func(i, j int) bool {
+		a, b := locations[i], locations[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Address < b.Address
+	})
+}
+
+func containsLocation(locations []Location, location Location) bool {
+	for _, l := range locations {
+		if l == location {
+			return true
+		}
+	}
+
+	return false
+}
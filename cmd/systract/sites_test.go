@@ -0,0 +1,42 @@
+package systract
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestAnalyse_E2E_Sites(t *testing.T) {
+	should := should.New(t)
+	filePath, _ := filepath.Abs("../../test/param-wrappers.dump")
+
+	actual, err := Analyse(NewDumpReader(filePath))
+
+	should.NotError(err, "should analyse dump")
+	should.BeEqual([]SystemCall{
+		{ID: 39, Name: "getpid", Sites: []Location{
+			{Symbol: "main.main", File: "/app/main.go", Line: 10, Address: 0x401005},
+		}},
+		{ID: 72, Name: "fcntl", Sites: []Location{
+			{Symbol: "main.main", File: "/app/main.go", Line: 12, Address: 0x40101e},
+		}},
+		{ID: 231, Name: "exit_group", Sites: []Location{
+			{Symbol: "main.main", File: "/app/main.go", Line: 11, Address: 0x40100f},
+		}},
+	}, actual.SystemCalls, "should list the sites issuing each syscall")
+}
+
+func TestGetSymbolFile(t *testing.T) {
+	assertThat := func(assumption, line, expected string) {
+		should := should.New(t)
+
+		actual := getSymbolFile(line)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should return the source file", "TEXT main.main(SB) /app/main.go", "/app/main.go")
+	assertThat("should return autogenerated files", "TEXT type:.eq.[8SM10K6](SB) <autogenerated>", "<autogenerated>")
+	assertThat("should return empty when not set", "TEXT main.main(SB)", "")
+}
@@ -0,0 +1,110 @@
+package systract
+
+import (
+	"errors"
+	"io"
+)
+
+// fileSource is implemented by source readers whose input is a file,
+// returning its path.
+type fileSource interface {
+	file() (string, error)
+}
+
+// resultSource is implemented by sources returning results of their own,
+// e.g. loaded from a cache, instead of having their symbols analysed.
+type resultSource interface {
+	analyse() (*Result, error)
+}
+
+// symbolSource is implemented by sources holding parsed symbols, which are
+// loaded as they are instead of parsing the source as a dump.
+type symbolSource interface {
+	symbols() (*archSpec, map[string]symbolDefinition, error)
+}
+
+// dumpParser is implemented by sources parsing their dump on their own,
+// e.g. into an on-disk index.
+type dumpParser interface {
+	parseDump(reader io.Reader, arch *archSpec, convention callingConvention, goVersion string) (map[string]symbolDefinition, error)
+}
+
+// sourceWrapper wraps a source, forwarding all that it is able to tell about its input.
+type sourceWrapper struct {
+	source SourceReader
+}
+
+// GetReader returns the reader of the source wrapped.
+func (w sourceWrapper) GetReader() (io.ReadCloser, error) {
+	return w.source.GetReader()
+}
+
+// arch returns the architecture of the source wrapped, if it can determine it.
+func (w sourceWrapper) arch() (string, error) {
+	if s, ok := w.source.(archSource); ok {
+		return s.arch()
+	}
+
+	return defaultArch, nil
+}
+
+// goVersion returns the go version of the source wrapped, if it can determine it.
+func (w sourceWrapper) goVersion() (string, error) {
+	if s, ok := w.source.(versionSource); ok {
+		return s.goVersion()
+	}
+
+	return "", errors.New("go version not supported by source")
+}
+
+// modules returns the modules of the source wrapped, if it can determine them.
+func (w sourceWrapper) modules() ([]string, error) {
+	if s, ok := w.source.(moduleSource); ok {
+		return s.modules()
+	}
+
+	return nil, errors.New("modules not supported by source")
+}
+
+// file returns the path of the file of the source wrapped, if it has one.
+func (w sourceWrapper) file() (string, error) {
+	if s, ok := w.source.(fileSource); ok {
+		return s.file()
+	}
+
+	return "", errors.New("source is not a file")
+}
+
+// parseDump parses the dump as the source wrapped would, in memory unless it parses it on its own.
+func (w sourceWrapper) parseDump(reader io.Reader, arch *archSpec, convention callingConvention, goVersion string) (map[string]symbolDefinition, error) {
+	if s, ok := w.source.(dumpParser); ok {
+		return s.parseDump(reader, arch, convention, goVersion)
+	}
+
+	return parseDump(reader, arch, convention), nil
+}
+
+// syscallWrappers returns the syscall wrappers of the source wrapped, if any.
+func (w sourceWrapper) syscallWrappers() []SyscallWrapper {
+	if s, ok := w.source.(wrapperSource); ok {
+		return s.syscallWrappers()
+	}
+
+	return nil
+}
+
+// wrapped returns the source wrapped.
+func (w sourceWrapper) wrapped() SourceReader {
+	return w.source
+}
+
+// unwrapSource returns the source at the bottom of all wrappers.
+func unwrapSource(source SourceReader) SourceReader {
+	for {
+		w, ok := source.(interface{ wrapped() SourceReader })
+		if !ok {
+			return source
+		}
+		source = w.wrapped()
+	}
+}
@@ -2,33 +2,71 @@
 package systract
 
 import (
-	"bufio"
 	"io"
 	"regexp"
-	"strconv"
-	"sync"
-
-	"github.com/golang-collections/collections/stack"
+	"sort"
+	"strings"
 )
 
 const (
 	symbolDefinitionRegex     string = "TEXT.((\\%|\\(|\\)|\\*|[a-zA-Z0-9_.\\/])+)\\b\\("
 	initSymbolDefinitionRegex string = "((\\%|\\(|\\)|\\*|[a-zA-Z0-9_.\\/])+\\.init)\\b"
-	syscallHexIDRegex         string = "MOV(Q|L).\\$0x([0-9a-fA-F]+)"
-	callCaptureRegex          string = ".+CALL.(\\b([a-zA-Z0-9_.\\/]|\\.|\\(\\*[a-zA-Z0-9_.\\/]+\\))+\\b)+"
-	syscallCallRegex          string = "SYSCALL|golang.org/x/sys/unix.Syscall|syscall.Syscall"
+)
+
+var (
+	symbolDefinitionPattern = regexp.MustCompile(symbolDefinitionRegex)
+	initSymbolPattern       = regexp.MustCompile(initSymbolDefinitionRegex)
 )
 
 // SystemCall represents a system call
 type SystemCall struct {
-	ID   uint16
-	Name string
+	ID   uint16 `json:"id"`
+	Name string `json:"name"`
+	// Sites contains the locations issuing the system call, sorted by file and line.
+	Sites []Location `json:"sites,omitempty"`
+}
+
+// Result represents the outcome of the extraction of system calls from a source
+type Result struct {
+	// Arch is the architecture detected for the source, using GOARCH naming.
+	Arch string `json:"arch"`
+	// GoVersion is the go version used to build the source, when available.
+	GoVersion   string       `json:"goVersion,omitempty"`
+	SystemCalls []SystemCall `json:"systemCalls"`
+	// Packages groups the system calls by the package whose code issues them.
+	Packages []PackageSyscalls `json:"packages,omitempty"`
+	// RuntimeRoots contains the runtime symbols which were considered entry points,
+	// as they are executed without being called, e.g. signal handlers.
+	RuntimeRoots []string `json:"runtimeRoots,omitempty"`
+	// Confidence contains the highest confidence each system call was resolved with, by name.
+	Confidence map[string]Confidence `json:"confidence,omitempty"`
+	// Unresolved contains the syscall sites and calls which could not be fully resolved,
+	// and may therefore issue system calls which were not found.
+	Unresolved []Unresolved `json:"unresolved,omitempty"`
 }
 
 type symbolDefinition struct {
 	name       string
 	syscallIDs []uint16
 	subCalls   []string
+	// types contains the concrete types referenced through itabs or type descriptors.
+	types []string
+	// references contains the symbols whose address is taken, e.g. function values.
+	references []string
+	// confidence contains the confidence each syscall id was resolved with.
+	confidence map[uint16]Confidence
+	// sites contains the locations issuing each syscall id.
+	sites map[uint16][]Location
+	// params contains the positions of the parameters used as syscall numbers, for parameterised wrappers.
+	params []int
+	// calls contains the direct calls to other functions, alongside the syscall numbers and
+	// parameters passed as arguments. They are dropped once all symbols are linked.
+	calls []callArgs
+	// unresolved contains the syscall sites and calls which could not be fully resolved.
+	unresolved []Unresolved
+	// indirectCalls tells whether the symbol calls functions through registers or memory,
+	// e.g. runtime.sigtramp, which makes it worth keeping even when it calls nothing else.
+	indirectCalls bool
 }
 
 // SourceReader defines the interface for source readers
@@ -38,186 +76,297 @@ type SourceReader interface {
 
 // Extract returns all system calls made in the execution path of the dumpFile provided.
 func Extract(source SourceReader) ([]SystemCall, error) {
-	reader, err := source.GetReader()
+	result, err := Analyse(source)
 	if err != nil {
 		return nil, err
 	}
-	defer reader.Close()
 
-	symbols := parseDump(reader)
-	syscalls := extractSyscalls(symbols)
+	return result.SystemCalls, nil
+}
+
+// Analyse returns all system calls made in the execution path of the source provided,
+// alongside the architecture it was detected for. Results of cached sources are
+// loaded from their cache when the same input was analysed before.
+func Analyse(source SourceReader) (*Result, error) {
+	if s, ok := source.(resultSource); ok {
+		return s.analyse()
+	}
 
-	return syscalls, nil
+	return analyseSymbols(source)
 }
 
-func getEntryPoints(symbols map[string]symbolDefinition) (ep []string) {
-	ep = append(ep, "main.main", "main.init.0", "main.init.1")
-	ep = append(ep, extractInitSymbols(symbols)...)
-	return
+// analyseSymbols analyses the source holding all of its symbols in memory.
+func analyseSymbols(source SourceReader) (*Result, error) {
+	arch, symbols, err := parseSource(source)
+	if err != nil {
+		return nil, err
+	}
+
+	goVersion := getGoVersion(source)
+	roots := findRuntimeRoots(symbols, arch.name, goVersion)
+	entryPoints := getEntryPoints(symbols, roots)
+
+	graph := newCallGraph(symbols)
+	syscalls := extractSyscalls(graph, arch, entryPoints)
+	reachable := graph.reachableSymbols(entryPoints)
+	addSyscallSites(syscalls, symbols, reachable)
+
+	return &Result{
+		Arch:         arch.name,
+		GoVersion:    goVersion,
+		SystemCalls:  syscalls,
+		Packages:     attributeSyscalls(symbols, arch, reachable, getModules(source)),
+		RuntimeRoots: roots,
+		Confidence:   syscallConfidence(symbols, arch, reachable),
+		Unresolved:   findUnresolved(symbols, reachable),
+	}, nil
 }
 
-// kick off process from executable key entry points.
-func extractSyscalls(symbols map[string]symbolDefinition) []SystemCall {
-	syscallID := make(chan uint16)
+// parseSource reads all symbols from the source, alongside its architecture.
+// Sources parsing their dump on their own, e.g. bounded sources, may only return
+// the symbols reachable from the entry points. Sources holding parsed symbols, e.g.
+// symbol graphs, are loaded as saved, however they are wrapped.
+func parseSource(source SourceReader) (*archSpec, map[string]symbolDefinition, error) {
+	if s, ok := unwrapSource(source).(symbolSource); ok {
+		return s.symbols()
+	}
 
-	var wg sync.WaitGroup
-	entryPoints := getEntryPoints(symbols)
-	wg.Add(len(entryPoints))
-	for _, symbol := range entryPoints {
-		go func(s string) {
-			dumpWalker(symbols, s, syscallID)
-			wg.Done()
-		}(symbol)
+	reader, arch, err := openSource(source)
+	if err != nil {
+		return nil, nil, err
 	}
+	defer reader.Close()
+
+	goVersion := getGoVersion(source)
+	convention := getCallingConvention(arch, goVersion)
+	if s, ok := source.(dumpParser); ok {
+		symbols, err := s.parseDump(reader, arch, convention, goVersion)
+		return arch, symbols, err
+	}
+
+	return arch, parseDump(reader, arch, convention), nil
+}
+
+// openSource returns the reader of the source, alongside the architecture its
+// dump is parsed with.
+func openSource(source SourceReader) (io.ReadCloser, *archSpec, error) {
+	reader, err := source.GetReader()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	arch, err := getArch(source)
+	if err != nil {
+		reader.Close()
+		return nil, nil, err
+	}
+	wrappers, err := getSyscallWrappers(source)
+	if err != nil {
+		reader.Close()
+		return nil, nil, err
+	}
+
+	return reader, arch.withSyscallWrappers(wrappers), nil
+}
 
-	go func() {
-		wg.Wait()
-		close(syscallID)
-	}()
+// getEntryPoints returns the main and init functions, followed by the runtime roots.
+func getEntryPoints(symbols map[string]symbolDefinition, runtimeRoots []string) (ep []string) {
+	ep = append(ep, "main.main", "main.init.0", "main.init.1")
+	ep = append(ep, extractInitSymbols(symbols)...)
+	ep = append(ep, runtimeRoots...)
+	return
+}
 
+// extractSyscalls returns the system calls reachable from the entry points, in the
+// order they are first reached.
+func extractSyscalls(graph *callGraph, arch *archSpec, entryPoints []string) []SystemCall {
 	syscalls := make([]SystemCall, 0)
 	unique := make(map[uint16]bool)
 
-	for id := range syscallID {
-		if _, exists := unique[id]; !exists {
-			unique[id] = true
-			syscalls = append(syscalls, SystemCall{
-				ID:   id,
-				Name: systemCalls[id],
-			})
+	for _, symbol := range entryPoints {
+		for _, id := range graph.reachableSyscalls(symbol) {
+			if _, exists := unique[id]; !exists {
+				unique[id] = true
+				syscalls = append(syscalls, SystemCall{
+					ID:   id,
+					Name: arch.systemCalls[id],
+				})
+			}
 		}
 	}
 
 	return syscalls
 }
 
-func parseDump(reader io.Reader) map[string]symbolDefinition {
-	symbols := make(map[string]symbolDefinition)
-	scanner := bufio.NewScanner(reader)
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		stack := stack.New()
-		symbol := symbolDefinition{
-			subCalls:   make([]string, 0),
-			syscallIDs: make([]uint16, 0),
-		}
-		symbolName, found := getSymbolName(line)
-
-		for found {
-			if scanner.Scan() {
-				line = scanner.Text()
-				if isEndOfSymbol(line) {
-					break
-				}
-
-				if id, found := tryPopSyscallID(line, stack); found {
-					symbol.syscallIDs = append(symbol.syscallIDs, id)
-					continue
-				}
-
-				if subcall, found := getCallTarget(line); found {
-					symbol.subCalls = append(symbol.subCalls, subcall)
-					continue
-				}
-
-				stackSyscallIDIfNecessary(line, stack)
-			} else {
-				break
+// syscallConfidence returns the highest confidence each syscall issued by the
+// reachable symbols was resolved with.
+func syscallConfidence(symbols map[string]symbolDefinition, arch *archSpec, reachable []string) map[string]Confidence {
+	confidence := make(map[string]Confidence)
+	for _, name := range reachable {
+		for id, c := range symbols[name].confidence {
+			syscall := arch.systemCalls[id]
+			if existing, found := confidence[syscall]; !found || c.rank() > existing.rank() {
+				confidence[syscall] = c
 			}
 		}
-
-		if len(symbol.subCalls) > 0 || len(symbol.syscallIDs) > 0 {
-			symbols[symbolName] = symbol
-		}
 	}
 
-	return symbols
+	return confidence
 }
 
-func dumpWalker(symbols map[string]symbolDefinition, symbolName string, syscallID chan<- uint16) {
-	var walk func(symbol string)
-	processed := make(map[string]bool)
+// parseSymbol parses the instructions of a symbol. Syscall numbers are resolved through
+// data-flow analysis, reporting the syscall sites it could not resolve as unresolved.
+func parseSymbol(name, file string, lines []string, arch *archSpec, convention callingConvention) symbolDefinition {
+	insts := parseInstructions(lines, arch)
+	sites, calls := resolveSyscallSites(insts, arch, convention)
+	for i := range calls {
+		calls[i].location = getLocation(name, file, lines[calls[i].index])
+	}
 
-	walk = func(symbol string) {
-		if _, exists := processed[symbol]; !exists {
-			processed[symbol] = true
-			if s, found := symbols[symbol]; found {
-				for _, id := range s.syscallIDs {
-					syscallID <- id
-				}
+	symbol := symbolDefinition{
+		subCalls:   make([]string, 0),
+		syscallIDs: make([]uint16, 0),
+		calls:      calls,
+	}
 
-				for _, name := range s.subCalls {
-					walk(name)
-				}
+	for i, line := range lines {
+		if site, resolved := sites[i]; resolved {
+			location := getLocation(name, file, line)
+			symbol.addSyscallSite(site, location)
+			for _, id := range site.unknownIDs {
+				symbol.unresolved = append(symbol.unresolved, Unresolved{
+					Reason: UnresolvedUnknownID, Location: location, ID: id,
+				})
 			}
+			continue
+		}
+		if containsSyscall(insts[i], arch) {
+			symbol.unresolved = append(symbol.unresolved, Unresolved{
+				Reason: UnresolvedDynamic, Location: getLocation(name, file, line),
+			})
 		}
-	}
 
-	walk(symbolName)
-}
+		if insts[i].target != "" {
+			symbol.subCalls = append(symbol.subCalls, insts[i].target)
+			continue
+		}
+		if isCall(insts[i]) {
+			symbol.indirectCalls = true
+			continue
+		}
 
-func stackSyscallIDIfNecessary(assemblyLine string, s *stack.Stack) {
-	if id, ok := getSyscallID(assemblyLine); ok {
-		s.Push(id)
+		if typeName, found := getTypeReference(line); found {
+			symbol.types = append(symbol.types, typeName)
+			continue
+		}
+
+		if reference, found := getFunctionReference(line); found {
+			symbol.references = append(symbol.references, reference)
+		}
 	}
+
+	return symbol
 }
 
-func tryPopSyscallID(assemblyLine string, s *stack.Stack) (uint16, bool) {
-	if s.Len() > 0 && containsSyscall(assemblyLine) {
-		val1 := s.Pop()
-		val2 := s.Pop()
+// addSyscallID adds the syscall id issued at the location, keeping the highest
+// confidence it was resolved with. It returns whether the symbol changed.
+func (s *symbolDefinition) addSyscallID(id uint16, confidence Confidence, location Location) bool {
+	if s.confidence == nil {
+		s.confidence = make(map[uint16]Confidence)
+		s.sites = make(map[uint16][]Location)
+	}
 
-		syscallID := val1
-		if val2 != nil {
-			syscallID = val2
-		}
-		return syscallID.(uint16), true
+	changed := false
+	existing, found := s.confidence[id]
+	if !found {
+		s.syscallIDs = append(s.syscallIDs, id)
+	}
+	if !found || confidence.rank() > existing.rank() {
+		s.confidence[id] = confidence
+		changed = true
+	}
+	if !containsLocation(s.sites[id], location) {
+		s.sites[id] = append(s.sites[id], location)
+		changed = true
 	}
 
-	return 0, false
+	return changed
 }
 
-func getSyscallID(assemblyLine string) (uint16, bool) {
-	re := regexp.MustCompile(syscallHexIDRegex)
-	captures := re.FindStringSubmatch(assemblyLine)
+// addSyscallSite adds the syscall ids and parameters of the site at the location,
+// returning whether the symbol changed.
+func (s *symbolDefinition) addSyscallSite(site syscallSite, location Location) bool {
+	changed := false
+	for _, id := range site.ids {
+		changed = s.addSyscallID(id, site.confidence, location) || changed
+	}
 
-	if captures != nil && len(captures) > 0 {
-		if n, err := strconv.ParseUint(captures[2], 16, 16); err == nil {
-			id := uint16(n)
-			if _, exists := systemCalls[id]; exists {
-				return id, true
-			}
+	for _, pos := range site.params {
+		if !containsInt(s.params, pos) {
+			s.params = append(s.params, pos)
+			changed = true
 		}
 	}
 
-	return 0, false
+	return changed
 }
 
-func getSymbolName(assemblyLine string) (string, bool) {
-	return extract(assemblyLine, symbolDefinitionRegex)
+// getSyscallWrapperCall returns the position of the trap argument when the instruction
+// is a call to a known syscall wrapper, or to its ABI0 assembly implementation.
+func getSyscallWrapperCall(inst instruction, arch *archSpec) (int, bool) {
+	if inst.target == "" {
+		return 0, false
+	}
+
+	return arch.syscallWrapper(strings.TrimSuffix(inst.target, abi0Suffix))
 }
 
-func getCallTarget(assemblyLine string) (string, bool) {
-	return extract(assemblyLine, callCaptureRegex)
+// getTrapArgLocations returns where the trap argument is stored when the instruction is
+// a call to a known syscall wrapper. ABI0 implementations always use the stack.
+func getTrapArgLocations(inst instruction, arch *archSpec, convention callingConvention) ([]string, bool) {
+	trapArg, isWrapper := getSyscallWrapperCall(inst, arch)
+	if !isWrapper {
+		return nil, false
+	}
+
+	if strings.HasSuffix(inst.target, abi0Suffix) {
+		convention = stackConvention
+	}
+
+	return convention.argLocations(arch, trapArg), true
 }
 
-func extract(assemblyLine, regex string) (string, bool) {
-	re := regexp.MustCompile(regex)
-	captures := re.FindStringSubmatch(assemblyLine)
+func getSymbolName(assemblyLine string) (string, bool) {
+	if !strings.HasPrefix(assemblyLine, "TEXT") {
+		return "", false
+	}
 
-	if captures != nil && len(captures) > 0 {
+	if captures := symbolDefinitionPattern.FindStringSubmatch(assemblyLine); captures != nil {
 		return captures[1], true
 	}
 
+	// generic instantiations have their type arguments in their name, e.g. slices.Sort[go.shape.int].
+	if strings.HasPrefix(assemblyLine, "TEXT ") {
+		if i := strings.Index(assemblyLine, "(SB)"); i > len("TEXT ") {
+			return assemblyLine[len("TEXT "):i], true
+		}
+	}
+
 	return "", false
 }
 
-func containsSyscall(assemblyLine string) bool {
-	re := regexp.MustCompile(syscallCallRegex)
-	captures := re.FindStringSubmatch(assemblyLine)
+// containsSyscall checks whether the instruction issues a system call,
+// or calls a known syscall wrapper.
+func containsSyscall(inst instruction, arch *archSpec) bool {
+	if inst.op == "" {
+		return false
+	}
+	if arch.isSyscall(inst.text) {
+		return true
+	}
 
-	return (captures != nil && len(captures) > 0)
+	_, isWrapper := getSyscallWrapperCall(inst, arch)
+	return isWrapper
 }
 
 func isEndOfSymbol(line string) bool {
@@ -225,10 +374,7 @@ func isEndOfSymbol(line string) bool {
 }
 
 func isInitSymbol(line string) bool {
-	re := regexp.MustCompile(initSymbolDefinitionRegex)
-	captures := re.FindStringSubmatch(line)
-
-	return (captures != nil && len(captures) > 0)
+	return strings.Contains(line, ".init") && initSymbolPattern.MatchString(line)
 }
 
 func extractInitSymbols(symbols map[string]symbolDefinition) (initSymbols []string) {
@@ -237,5 +383,6 @@ func extractInitSymbols(symbols map[string]symbolDefinition) (initSymbols []stri
 			initSymbols = append(initSymbols, k)
 		}
 	}
+	sort.Strings(initSymbols)
 	return
 }
@@ -1,10 +1,12 @@
 package systract
 
 import (
+	"bytes"
+	"io/ioutil"
 	"path/filepath"
+	"runtime"
 	"testing"
 
-	"github.com/golang-collections/collections/stack"
 	"github.com/pjbgf/go-test/should"
 )
 
@@ -40,36 +42,90 @@ func TestExtract_E2E_Executable(t *testing.T) {
 	actual, err := Extract(NewDumpReader(fileName))
 
 	should.BeNil(err, "should not error for keyring.dump")
-	should.HaveSameItems(expected, actual, "should match expected syscalls for keyring.dump")
+	should.HaveSameItems(keys(expected), keys(actual), "should match expected syscalls for keyring.dump")
 }
 
-func TestGetSyscallID(t *testing.T) {
-	assertThat := func(assumption, assemblyLine string, expectedId uint16, expectedMatch bool) {
+func TestAnalyse_E2E_ArchDumps(t *testing.T) {
+	assertThat := func(assumption, fileName, expectedArch string, expected []SystemCall) {
 		should := should.New(t)
+		filePath, _ := filepath.Abs(fileName)
 
-		id, ok := getSyscallID(assemblyLine)
+		actual, err := Analyse(NewDumpReader(filePath))
 
-		should.BeEqual(expectedMatch, ok, assumption)
-		should.BeEqual(expectedId, id, assumption)
+		should.BeNil(err, assumption)
+		should.BeEqual(expectedArch, actual.Arch, assumption)
+		should.HaveSameItems(keys(expected), keys(actual.SystemCalls), assumption)
 	}
 
-	assertThat("should support golang.org/x/sys/unix.Syscall calls", "zsyscall_linux_amd64.go:442	0x48bd75		48c704247d000000	MOVQ $0x7d, 0(SP)", 125, true)
-	assertThat("should support SYSCALL calls", "sys_linux_amd64.s:625	0x453610		b818000000		MOVL $0x18, AX", 24, true)
+	assertThat("should resolve names from the amd64 syscall table", "../../test/single-syscall.dump",
+		"amd64", []SystemCall{{ID: 231, Name: "exit_group"}})
+	assertThat("should resolve names from the arm64 syscall table", "../../test/arm64-single-syscall.dump",
+		"arm64", []SystemCall{{ID: 94, Name: "exit_group"}})
+	assertThat("should resolve names from the 386 syscall table", "../../test/386-single-syscall.dump",
+		"386", []SystemCall{{ID: 252, Name: "exit_group"}})
+	assertThat("should resolve names from the arm syscall table", "../../test/arm-single-syscall.dump",
+		"arm", []SystemCall{{ID: 248, Name: "exit_group"}})
+	assertThat("should resolve names from the riscv64 syscall table", "../../test/riscv64-single-syscall.dump",
+		"riscv64", []SystemCall{{ID: 94, Name: "exit_group"}})
+	assertThat("should resolve names from the ppc64le syscall table", "../../test/ppc64le-single-syscall.dump",
+		"ppc64le", []SystemCall{{ID: 234, Name: "exit_group"}})
+	assertThat("should resolve names from the s390x syscall table", "../../test/s390x-single-syscall.dump",
+		"s390x", []SystemCall{{ID: 248, Name: "exit_group"}})
+}
+
+func TestAnalyse_Errors(t *testing.T) {
+	should := should.New(t)
+
+	result, err := Analyse(NewDumpReader("/tmp/3216763872163876321"))
+
+	should.Error(err, "should error when input file does not exist")
+	should.BeNil(result, "should not return results when input file does not exist")
 }
 
 func TestIsCallInstruction(t *testing.T) {
 	assertThat := func(assumption, assemblyLine, expectedTarget string, expectedMatch bool) {
 		should := should.New(t)
-		target, ok := getCallTarget(assemblyLine)
+		target := parseInstructions([]string{assemblyLine}, archs["amd64"])[0].target
 
-		should.BeEqual(expectedMatch, ok, assumption)
+		should.BeEqual(expectedMatch, target != "", assumption)
 		should.BeEqual(expectedTarget, target, assumption)
 	}
 
 	assertThat("should match runtime funcs", "main.go:35		0x48c3d8		e8c334fcff		CALL runtime.morestack_noctxt(SB)", "runtime.morestack_noctxt", true)
 	assertThat("should match composed funcs", "print.go:265		0x481553		e8c87b0000		CALL fmt.(*pp).doPrintln(SB)", "fmt.(*pp).doPrintln", true)
 	assertThat("should match composed funcs 2", "print.go:134		0x480f9c		e84ff7fdff		CALL sync.(*Pool).Get(SB)", "sync.(*Pool).Get", true)
+	assertThat("should match generic funcs", "sort.go:32		0x4c8e1b		e8a0f2ffff		CALL slices.pdqsortCmpFunc[go.shape.int](SB)	", "slices.pdqsortCmpFunc[go.shape.int]", true)
+	assertThat("should match methods of generic types", "arshal_default.go:1637	0x560182		e8d97e0000		CALL encoding/json/v2.(*typedArshalers[go.shape.struct { encoding/json/jsontext.s encoding/json/jsontext.encoderState }]).lookup(SB)	",
+		"encoding/json/v2.(*typedArshalers[go.shape.struct { encoding/json/jsontext.s encoding/json/jsontext.encoderState }]).lookup", true)
 	assertThat("should not match funcs definition", "TEXT fmt.Fprintln(SB) /usr/local/go/src/fmt/print.go", "", false)
+	assertThat("should match calls followed by mnemonics without targets", "main.go:35		0x48c3d8		e8c334fcff		CALL main.run(SB)		[1:5]R_CALL", "main.run", true)
+	assertThat("should not match lines without calls", "main.go:35		0x48c3d8		4889442408		MOVQ AX, 0x8(SP)", "", false)
+	assertThat("should match generic funcs with several type arguments", "iter.go:12		0x4c8e1b		e8a0f2ffff		CALL maps.Keys[go.shape.map[string]int,go.shape.string](SB)	",
+		"maps.Keys[go.shape.map[string]int,go.shape.string]", true)
+	assertThat("should match calls to addresses", "memmove.go:10		0x48c3d8		e8c334fcff		CALL 0x45441c", "0x45441c", true)
+	assertThat("should drop offsets of calls into funcs", "memmove.go:10		0x48c3d8		e8c334fcff		CALL runtime.duffzero+0x10(SB)", "runtime.duffzero", true)
+	assertThat("should not match calls to registers", "proc.go:10		0x48c3d8		ffd0			CALL AX", "", false)
+	assertThat("should not match calls to memory operands", "proc.go:10		0x48c3d8		ff5010			CALL 0x10(AX)", "", false)
+	assertThat("should match call relocations of object files", "systrac.go:37		0x5c22			e800000000		CALL 0x5c27		[1:5]R_CALL:runtime.makemap_small	",
+		"runtime.makemap_small", true)
+	assertThat("should match tail calls of object files", "<autogenerated>:1	0x5b4c			e900000000		JMP 0x5b51		[1:5]R_CALL:os.(*file).close	",
+		"os.(*file).close", true)
+	assertThat("should not match indirect call relocations", "<autogenerated>:1	0x5b9f			ffd0			CALL AX				[0:0]R_CALLIND		", "", false)
+}
+
+func TestIsCallInstruction_ARM(t *testing.T) {
+	assertThat := func(assumption, assemblyLine, expectedTarget string, expectedMatch bool) {
+		should := should.New(t)
+		target := parseInstructions([]string{assemblyLine}, archs["arm"])[0].target
+
+		should.BeEqual(expectedMatch, target != "", assumption)
+		should.BeEqual(expectedTarget, target, assumption)
+	}
+
+	assertThat("should match branch with link to funcs", "syscall_linux.go:74	0xaf0d0			ebffabf2		BL runtime.entersyscall(SB)", "runtime.entersyscall", true)
+	assertThat("should match branch with link to composed funcs", "print.go:265	0xa93e8			eb000b2e		BL fmt.(*pp).doPrintln(SB)", "fmt.(*pp).doPrintln", true)
+	assertThat("should not match branch with link to registers", "asm_arm.s:263	0x7d3c8			e12fff33		BLX R3", "", false)
+	assertThat("should not match conditional branches", "proc.go:142	0x4b6a0			9a000010		BLS 0x4b6e8", "", false)
 }
 
 func TestGetSymbolName(t *testing.T) {
@@ -84,6 +140,8 @@ func TestGetSymbolName(t *testing.T) {
 	assertThat("should match main.main symbol", "TEXT main.main(SB) /media/pjb/src/git/learn-golang/caps/main.go", "main.main", true)
 	assertThat("should match symbol with *", "TEXT sync.(*Pool).Get(SB) /usr/local/go/src/sync/pool.go", "sync.(*Pool).Get", true)
 	assertThat("should match symbols with %", "TEXT %22%22.init(SB) gofile..<autogenerated>", "%22%22.init", true)
+	assertThat("should match generic symbols", "TEXT internal/bytealg.IndexRabinKarp[go.shape.[]uint8](SB) /usr/local/go/src/internal/bytealg/bytealg.go",
+		"internal/bytealg.IndexRabinKarp[go.shape.[]uint8]", true)
 }
 
 func TestIsEndOfSymbolDefinition(t *testing.T) {
@@ -102,7 +160,13 @@ func TestIsEndOfSymbolDefinition(t *testing.T) {
 func TestContainsSyscall(t *testing.T) {
 	assertThat := func(assumption, assemblyLine string, expected bool) {
 		should := should.New(t)
-		containsSyscall := containsSyscall(assemblyLine)
+		containsSyscall := containsSyscall(parseInstructions([]string{assemblyLine}, archs["amd64"])[0], archs["amd64"])
+
+		should.BeEqual(expected, containsSyscall, assumption)
+	}
+	assertThatArch := func(assumption, arch, assemblyLine string, expected bool) {
+		should := should.New(t)
+		containsSyscall := containsSyscall(parseInstructions([]string{assemblyLine}, archs[arch])[0], archs[arch])
 
 		should.BeEqual(expected, containsSyscall, assumption)
 	}
@@ -112,38 +176,19 @@ func TestContainsSyscall(t *testing.T) {
 	assertThat("should return true for SYSCALL instruction", "sys_linux_amd64.s:535	0x4534f1		0f05			SYSCALL", true)
 	assertThat("should return true for golang.org/x/sys/unix.Syscall instruction", "zsyscall_linux_amd64.go:442	0x48bd9a		e881030000		CALL golang.org/x/sys/unix.Syscall(SB)", true)
 	assertThat("should return false for instructions containing syscall on their name", "proc.go:2853		0x430ab3		eb8b			JMP runtime.entersyscall_sysmon(SB)", false)
-}
-
-func TestTryPopSyscallID(t *testing.T) {
-	assertThat := func(assumption, assemblyLine string, expectedID uint16, s *stack.Stack, expectedMatch bool) {
-		should := should.New(t)
-		actual, ok := tryPopSyscallID(assemblyLine, s)
-
-		should.BeEqual(expectedMatch, ok, assumption)
-		should.BeEqual(expectedID, actual, assumption)
-	}
-
-	stack := stack.New()
-	stackSyscallIDIfNecessary("zsyscall_linux_amd64.go:442	0x48bd75		48c704247d000000	MOVQ $0x7d, 0(SP)				", stack)
-	stackSyscallIDIfNecessary("zsyscall_linux_amd64.go:442	0x48bd7d		488b442440		MOVQ 0x40(SP), AX				", stack)
-	stackSyscallIDIfNecessary("zsyscall_linux_amd64.go:442	0x48bd82		4889442408		MOVQ AX, 0x8(SP)					", stack)
-	stackSyscallIDIfNecessary("zsyscall_linux_amd64.go:442	0x48bd91		48c744241800000000	MOVQ $0x0, 0x18(SP)				", stack)
-
-	assertThat("should match main.main symbol", "zsyscall_linux_amd64.go:442	0x48bd9a		e881030000		CALL golang.org/x/sys/unix.Syscall(SB)", uint16(125), stack, true)
-}
-
-func TestStackSyscallIDIfNecessary(t *testing.T) {
-	should := should.New(t)
-	stack := stack.New()
-	stackSyscallIDIfNecessary("zsyscall_linux_amd64.go:442	0x48bd75		48c704247d000000	MOVQ $0x7d, 0(SP)", stack)
-	stackSyscallIDIfNecessary("sys_linux.go:230	0x49554e		48c70424fa000000		MOVQ $0xfa, 0(SP)", stack)
-	stackSyscallIDIfNecessary("sys_linux_amd64.s:616	0x453aa5		48c7c702100000		MOVQ $0x1002, DI", stack)
-	stackSyscallIDIfNecessary("sys_linux_amd64.s:617	0x453aac		48c7c09e000000		MOVQ $0x9e, AX", stack)
-
-	should.BeEqual(3, stack.Len(), "should only stack potential ids")
-	should.BeEqual(uint16(158), stack.Pop(), "should match ids in the correct order")
-	should.BeEqual(uint16(250), stack.Pop(), "should match ids in the correct order")
-	should.BeEqual(uint16(125), stack.Pop(), "should match ids in the correct order")
+	assertThatArch("should return true for arm64 SVC instruction", "arm64", "sys_linux_arm64.s:56	0x89f28			d4000001		SVC $0", true)
+	assertThatArch("should return true for arm64 syscall.Syscall calls", "arm64", "zsyscall_linux_arm64.go:1402	0x9c2b4			97fff02f		CALL syscall.Syscall(SB)", true)
+	assertThatArch("should return false for amd64 SYSCALL instruction on arm64", "arm64", "sys_linux_amd64.s:535	0x4534f1		0f05			SYSCALL", false)
+	assertThatArch("should return true for 386 INT 0x80 instruction", "386", "sys_linux_386.s:66	0x80cc1e9		cd80			INT $0x80", true)
+	assertThatArch("should return false for 386 breakpoints", "386", "sys_linux_386.s:67	0x80cc1eb		cd03			INT $0x3", false)
+	assertThatArch("should return true for arm SVC instruction", "arm", "sys_linux_arm.s:109	0x9fab0			ef000000		SVC $0", true)
+	assertThatArch("should return true for arm SWI instruction", "arm", "sys_linux_arm.s:109	0x9fab0			ef000000		SWI $0", true)
+	assertThatArch("should return true for riscv64 ECALL instruction", "riscv64", "sys_linux_riscv64.s:56	0x71d54			00000073		ECALL", true)
+	assertThatArch("should return true for ppc64le SC instruction", "ppc64le", "sys_linux_ppc64x.s:50	0x97188			44000002		SC $0", true)
+	assertThatArch("should return true for ppc64le SYSCALL instruction", "ppc64le", "sys_linux_ppc64x.s:50	0x97188			44000002		SYSCALL $0", true)
+	assertThatArch("should return false for ppc64le instructions containing SC", "ppc64le", "memmove_ppc64x.s:104	0x7e6a4			7ca02c0c		LXVD2X (R0)(R5), VS35", false)
+	assertThatArch("should return true for s390x SYSCALL instruction", "s390x", "sys_linux_s390x.s:46	0xa20aa			0a00			SYSCALL $0", true)
+	assertThatArch("should return true for s390x SVC instruction", "s390x", "sys_linux_s390x.s:46	0xa20aa			0a00			SVC $0", true)
 }
 
 func TestIsInitSymbol(t *testing.T) {
@@ -181,3 +226,79 @@ func TestExtractInitSymbols(t *testing.T) {
 			"reflect.(*funcTypeFixed64).Out":                symbolDefinition{},
 		}, []string{"runtime.(*gcWork).init", "github.com/pjbgf/gosystract/cmd/systract.init"})
 }
+
+func TestParseDump_Workers(t *testing.T) {
+	assertThat := func(assumption, fileName string, workers int) {
+		should := should.New(t)
+		data, err := ioutil.ReadFile(fileName)
+		should.NotError(err, assumption)
+		arch := archs["amd64"]
+
+		expected := parseDumpWith(bytes.NewReader(data), arch, stackConvention, 1)
+		actual := parseDumpWith(bytes.NewReader(data), arch, stackConvention, workers)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should parse the same symbols as a single worker", "../../test/systrac.dump", 4)
+	assertThat("should parse the same symbols with more workers than symbols", "../../test/single-syscall.dump", 64)
+	assertThat("should keep the last definition of duplicated symbols", "../../test/duplicated-symbols.dump", 4)
+}
+
+func TestParseDump_DuplicatedSymbols(t *testing.T) {
+	should := should.New(t)
+	data, err := ioutil.ReadFile("../../test/duplicated-symbols.dump")
+	should.NotError(err, "should read dump")
+
+	symbols := parseDumpWith(bytes.NewReader(data), archs["amd64"], stackConvention, 2)
+
+	should.BeEqual([]uint16{231}, symbols["main.f"].syscallIDs, "should keep the last definition of duplicated symbols")
+}
+
+func BenchmarkParseDump(b *testing.B) {
+	benchmarkParseDump(b, 1, runtime.GOMAXPROCS(0))
+}
+
+func BenchmarkParseDump_SingleWorker(b *testing.B) {
+	benchmarkParseDump(b, 1, 1)
+}
+
+func BenchmarkParseDump_Large(b *testing.B) {
+	benchmarkParseDump(b, 100, runtime.GOMAXPROCS(0))
+}
+
+func BenchmarkParseDump_LargeSingleWorker(b *testing.B) {
+	benchmarkParseDump(b, 100, 1)
+}
+
+// benchmarkParseDump measures the throughput of parsing test/systrac.dump repeated
+// the number of times provided, which yields dumps of about 100KB each time.
+func benchmarkParseDump(b *testing.B, times, workers int) {
+	data, err := ioutil.ReadFile("../../test/systrac.dump")
+	if err != nil {
+		b.Fatal(err)
+	}
+	data = bytes.Repeat(append(data, '\n'), times)
+	arch := archs["amd64"]
+
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		parseDumpWith(bytes.NewReader(data), arch, stackConvention, workers)
+	}
+}
+
+// comparableSyscall is the comparable part of a SystemCall, as HaveSameItems cannot compare sites.
+type comparableSyscall struct {
+	ID   uint16
+	Name string
+}
+
+func keys(syscalls []SystemCall) []comparableSyscall {
+	result := make([]comparableSyscall, len(syscalls))
+	for i, s := range syscalls {
+		result[i] = comparableSyscall{ID: s.ID, Name: s.Name}
+	}
+
+	return result
+}
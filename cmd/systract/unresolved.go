@@ -0,0 +1,163 @@
+package systract
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// locationRegex captures the source file, line and address of a go tool objdump line, e.g. main.go:10 0x401000.
+const locationRegex string = "^\\s*(\\S+):([0-9]+)\\s+(0x[0-9a-fA-F]+)?"
+
+var locationPattern = regexp.MustCompile(locationRegex)
+
+// UnresolvedReason describes why a syscall site or call could not be resolved.
+type UnresolvedReason string
+
+const (
+	// UnresolvedDynamic is used for syscall sites whose number could not be determined,
+	// including calls passing unknown values to parameterised wrappers.
+	UnresolvedDynamic UnresolvedReason = "dynamic"
+	// UnresolvedUnknownID is used for syscall numbers not in the syscall table of the architecture.
+	UnresolvedUnknownID UnresolvedReason = "unknown-id"
+	// UnresolvedUnknownTarget is used for calls to symbols not found in the source.
+	UnresolvedUnknownTarget UnresolvedReason = "unknown-target"
+)
+
+// Location represents where an instruction is within the source code.
+type Location struct {
+	Symbol  string `json:"symbol"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Address uint64 `json:"address,omitempty"`
+}
+
+// Unresolved represents a syscall site or call which could not be fully resolved,
+// and may therefore issue system calls which were not found.
+type Unresolved struct {
+	Reason UnresolvedReason `json:"reason"`
+	Location
+	// ID is the syscall number not found in the syscall table, for unknown-id sites and
+	// calls. It is always written, as zero is a valid syscall number on most architectures.
+	ID uint16 `json:"id"`
+	// Target is the symbol called, for calls to symbols not found or to parameterised wrappers.
+	Target string `json:"target,omitempty"`
+}
+
+func (l Location) String() string {
+	return fmt.Sprintf("%s:%d (%s)", l.File, l.Line, l.Symbol)
+}
+
+func (u Unresolved) String() string {
+	switch u.Reason {
+	case UnresolvedDynamic:
+		if u.Target != "" {
+			return fmt.Sprintf("%s: dynamic syscall number passed to %s", u.Location, u.Target)
+		}
+		return fmt.Sprintf("%s: dynamic syscall number", u.Location)
+	case UnresolvedUnknownID:
+		return fmt.Sprintf("%s: unknown syscall number %d", u.Location, u.ID)
+	case UnresolvedUnknownTarget:
+		return fmt.Sprintf("%s: call to unknown symbol %s", u.Location, u.Target)
+	}
+
+	return fmt.Sprintf("%s: %s", u.Location, u.Reason)
+}
+
+// getLocation returns the source location of a go tool objdump line within the symbol.
+// Dumps only show the base name of files, so the source file of the symbol is used instead
+// when their base names match, e.g. /app/main.go for main.go.
+func getLocation(symbol, symbolFile, assemblyLine string) Location {
+	location := Location{Symbol: symbol}
+	captures := locationPattern.FindStringSubmatch(assemblyLine)
+	if captures == nil {
+		return location
+	}
+
+	location.File = captures[1]
+	if symbolFile != "" && base(symbolFile) == location.File {
+		location.File = symbolFile
+	}
+	location.Line, _ = strconv.Atoi(captures[2])
+	location.Address, _ = strconv.ParseUint(captures[3], 0, 64)
+
+	return location
+}
+
+// isDirectCall checks whether the operand of a call is a symbol or an address,
+// e.g. main.main(SB) or 0x401000, instead of a register or memory operand.
+func isDirectCall(inst instruction) bool {
+	if !isCall(inst) || len(inst.args) != 1 {
+		return false
+	}
+
+	return strings.HasSuffix(inst.args[0], "(SB)") || isAddress(inst.args[0])
+}
+
+// linkUnresolvedCalls records calls to symbols which are not defined in the source,
+// and calls passing unknown or invalid values to parameterised wrappers.
+// The calls of each symbol are no longer needed afterwards, and are dropped.
+func linkUnresolvedCalls(symbols map[string]symbolDefinition, textSymbols map[string]bool, arch *archSpec) {
+	callee := func(target string) ([]int, bool) {
+		return symbols[target].params, textSymbols[target]
+	}
+
+	for name, symbol := range symbols {
+		addUnresolvedCalls(&symbol, callee, arch)
+		symbols[name] = symbol
+	}
+}
+
+// addUnresolvedCalls records the unresolved calls of the symbol, callee returning the
+// parameters of each callee and whether it is defined in the source, and drops its calls.
+func addUnresolvedCalls(symbol *symbolDefinition, callee func(target string) (params []int, text bool), arch *archSpec) {
+	for _, call := range symbol.calls {
+		params, text := callee(call.target)
+		if !text {
+			symbol.unresolved = append(symbol.unresolved, Unresolved{
+				Reason: UnresolvedUnknownTarget, Location: call.location, Target: call.target,
+			})
+			continue
+		}
+
+		for _, pos := range params {
+			site, _ := newSyscallSite(call.args[pos], arch)
+			if len(site.ids) == 0 && len(site.params) == 0 && len(site.unknownIDs) == 0 {
+				symbol.unresolved = append(symbol.unresolved, Unresolved{
+					Reason: UnresolvedDynamic, Location: call.location, Target: call.target,
+				})
+			}
+			for _, id := range site.unknownIDs {
+				symbol.unresolved = append(symbol.unresolved, Unresolved{
+					Reason: UnresolvedUnknownID, Location: call.location, ID: id, Target: call.target,
+				})
+			}
+		}
+	}
+
+	symbol.calls = nil
+}
+
+// findUnresolved returns the unresolved syscall sites and calls of the reachable
+// symbols, sorted by their location.
+func findUnresolved(symbols map[string]symbolDefinition, reachable []string) []Unresolved {
+	unresolved := make([]Unresolved, 0)
+	for _, name := range reachable {
+		unresolved = append(unresolved, symbols[name].unresolved...)
+	}
+
+	sort.SliceStable(unresolved, func(i, j int) bool {
+		a, b := unresolved[i], unresolved[j]
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+
+	return unresolved
+}
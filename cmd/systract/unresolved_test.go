@@ -0,0 +1,97 @@
+package systract
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestAnalyse_E2E_Unresolved(t *testing.T) {
+	should := should.New(t)
+	filePath, _ := filepath.Abs("../../test/unresolved.dump")
+
+	actual, err := Analyse(NewDumpReader(filePath))
+
+	should.NotError(err, "should analyse dump")
+	should.HaveSameItems(keys([]SystemCall{
+		{ID: 39, Name: "getpid"}, {ID: 231, Name: "exit_group"},
+	}), keys(actual.SystemCalls), "should find resolved syscalls")
+	should.BeEqual([]Unresolved{
+		{Reason: UnresolvedDynamic, Location: Location{Symbol: "main.clobbered", File: "/app/sys.go", Line: 11, Address: 0x40106a}},
+		{Reason: UnresolvedDynamic, Location: Location{Symbol: "main.main", File: "/app/main.go", Line: 10, Address: 0x401005}},
+		{Reason: UnresolvedUnknownID, Location: Location{Symbol: "main.main", File: "/app/main.go", Line: 11, Address: 0x40100c}, ID: 500},
+		{Reason: UnresolvedUnknownTarget, Location: Location{Symbol: "main.main", File: "/app/main.go", Line: 12, Address: 0x40100e}, Target: "main.missing"},
+		{Reason: UnresolvedDynamic, Location: Location{Symbol: "main.main", File: "/app/main.go", Line: 13, Address: 0x401018}, Target: "main.rawSyscall"},
+	}, actual.Unresolved, "should report unresolved syscall sites and calls")
+}
+
+func TestAnalyse_E2E_Unresolved_Executable(t *testing.T) {
+	assertThat := func(assumption string, source SourceReader) {
+		should := should.New(t)
+
+		actual, err := Analyse(source)
+
+		should.NotError(err, assumption)
+		should.BeEqual([]Unresolved{}, actual.Unresolved, assumption)
+	}
+
+	// calls into the middle of runtime.duffzero and runtime.duffcopy are shown as addresses.
+	assertThat("should resolve calls to addresses within symbols", NewELFReader("../../test/simple-app"))
+	assertThat("should resolve calls to addresses within symbols of bounded sources",
+		NewBoundedSource(NewELFReader("../../test/simple-app"), ""))
+}
+
+func TestGetLocation(t *testing.T) {
+	assertThat := func(assumption, symbolFile, line string, expected Location) {
+		should := should.New(t)
+
+		actual := getLocation("main.main", symbolFile, line)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should parse file, line and address", "", "  main.go:10		0x401000		0f05			SYSCALL",
+		Location{Symbol: "main.main", File: "main.go", Line: 10, Address: 0x401000})
+	assertThat("should use the source file of the symbol", "/app/main.go", "  main.go:10		0x401000		0f05			SYSCALL",
+		Location{Symbol: "main.main", File: "/app/main.go", Line: 10, Address: 0x401000})
+	assertThat("should keep files of inlined functions", "/app/main.go", "  sys.go:5		0x401000		0f05			SYSCALL",
+		Location{Symbol: "main.main", File: "sys.go", Line: 5, Address: 0x401000})
+	assertThat("should parse autogenerated files", "", "  <autogenerated>:1	0x401000		c3			RET",
+		Location{Symbol: "main.main", File: "<autogenerated>", Line: 1, Address: 0x401000})
+	assertThat("should keep the symbol when the line has no location", "", "TEXT main.main(SB) /app/main.go",
+		Location{Symbol: "main.main"})
+}
+
+func TestUnresolvedString(t *testing.T) {
+	assertThat := func(assumption string, unresolved Unresolved, expected string) {
+		should := should.New(t)
+
+		actual := unresolved.String()
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	location := Location{Symbol: "main.main", File: "main.go", Line: 10}
+	assertThat("should describe dynamic syscall sites", Unresolved{Reason: UnresolvedDynamic, Location: location},
+		"main.go:10 (main.main): dynamic syscall number")
+	assertThat("should describe dynamic syscall numbers passed to wrappers",
+		Unresolved{Reason: UnresolvedDynamic, Location: location, Target: "main.rawSyscall"},
+		"main.go:10 (main.main): dynamic syscall number passed to main.rawSyscall")
+	assertThat("should describe unknown syscall numbers", Unresolved{Reason: UnresolvedUnknownID, Location: location, ID: 500},
+		"main.go:10 (main.main): unknown syscall number 500")
+	assertThat("should describe calls to unknown symbols", Unresolved{Reason: UnresolvedUnknownTarget, Location: location, Target: "main.missing"},
+		"main.go:10 (main.main): call to unknown symbol main.missing")
+}
+
+func TestUnresolved_JSON(t *testing.T) {
+	should := should.New(t)
+	unresolved := Unresolved{Reason: UnresolvedUnknownID, Location: Location{Symbol: "main.main", File: "main.go", Line: 10}}
+
+	actual, err := json.Marshal(unresolved)
+
+	should.NotError(err, "should marshal unresolved sites")
+	should.BeEqual(`{"reason":"unknown-id","symbol":"main.main","file":"main.go","line":10,"id":0}`,
+		string(actual), "should keep syscall number zero")
+}
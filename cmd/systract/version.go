@@ -0,0 +1,84 @@
+package systract
+
+import (
+	"bytes"
+	"debug/buildinfo"
+	"debug/elf"
+	"errors"
+)
+
+// buildIDNoteName is the name of the elf note holding the go build id.
+const buildIDNoteName string = "Go\x00\x00"
+
+// versionSource is implemented by source readers that are able to
+// tell the go version used to build their input.
+type versionSource interface {
+	goVersion() (string, error)
+}
+
+// getGoVersion returns the go version of the source, or empty when it cannot be determined.
+func getGoVersion(source SourceReader) string {
+	if s, ok := source.(versionSource); ok {
+		if version, err := s.goVersion(); err == nil {
+			return version
+		}
+	}
+
+	return ""
+}
+
+// getBuildGoVersion returns the go version recorded in the build information of the executable.
+func getBuildGoVersion(filePath string) (string, error) {
+	info, err := buildinfo.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	return info.GoVersion, nil
+}
+
+// getBuildModules returns the main module followed by all dependencies
+// recorded in the build information of the executable.
+func getBuildModules(filePath string) ([]string, error) {
+	info, err := buildinfo.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := []string{info.Main.Path}
+	for _, dep := range info.Deps {
+		modules = append(modules, dep.Path)
+	}
+
+	return modules, nil
+}
+
+// getBuildID returns the go build id of the executable, stored in the .note.go.buildid
+// section as an elf note made of the sizes of its name and description, its type, the
+// name "Go" padded to four bytes and the build id itself.
+func getBuildID(filePath string) (string, error) {
+	f, err := elf.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	section := f.Section(".note.go.buildid")
+	if section == nil {
+		return "", errors.New("build id not found")
+	}
+	data, err := section.Data()
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < 16 || string(data[12:16]) != buildIDNoteName {
+		return "", errors.New("invalid build id note")
+	}
+	size := int(f.ByteOrder.Uint32(data[4:8]))
+	if size == 0 || 16+size > len(data) {
+		return "", errors.New("invalid build id note")
+	}
+
+	return string(bytes.TrimRight(data[16:16+size], "\x00")), nil
+}
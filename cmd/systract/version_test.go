@@ -0,0 +1,37 @@
+package systract
+
+import (
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestGetGoVersion(t *testing.T) {
+	assertThat := func(assumption string, source SourceReader, expected string) {
+		should := should.New(t)
+
+		actual := getGoVersion(source)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should read go version from executables", NewELFReader("../../test/simple-app"), "go1.13.4")
+	assertThat("should read go version from executables handled by go tool objdump", NewExeReader("../../test/simple-app"), "go1.13.4")
+	assertThat("should return empty for dump files", NewDumpReader("../../test/single-syscall.dump"), "")
+	assertThat("should return empty for files that are not executables", NewELFReader("../../test/single-syscall.dump"), "")
+}
+
+func TestGetBuildID(t *testing.T) {
+	assertThat := func(assumption, filePath, expected string, expectedErr bool) {
+		should := should.New(t)
+
+		actual, err := getBuildID(filePath)
+
+		should.BeEqual(expected, actual, assumption)
+		should.BeEqual(expectedErr, err != nil, assumption)
+	}
+
+	assertThat("should read go build id from executables", "../../test/simple-app",
+		"ViF4H98smBWqIKJpFg8q/jGai8vcekPZ-ymL0XXq3/AIVLLHEjn61Gu5__havt/CdGyM9D-t3oIy1sg8M6w", false)
+	assertThat("should error for files that are not executables", "../../test/single-syscall.dump", "", true)
+}
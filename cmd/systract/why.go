@@ -0,0 +1,119 @@
+package systract
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CallChain represents the symbols called from an entry point down to
+// the symbol which issues a system call.
+type CallChain []string
+
+func (c CallChain) String() string {
+	return strings.Join(c, " -> ")
+}
+
+// Why returns the call chains from the entry points of the source down to each
+// symbol issuing the given system call, shortest first.
+// The syscall can be either its name or its ID.
+func Why(source SourceReader, syscall string) ([]CallChain, error) {
+	arch, symbols, err := parseSource(source)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := resolveSyscallID(syscall, arch)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := findRuntimeRoots(symbols, arch.name, getGoVersion(source))
+
+	return findCallChains(symbols, getEntryPoints(symbols, roots), id), nil
+}
+
+// resolveSyscallID returns the ID of the system call based on its name or ID.
+func resolveSyscallID(syscall string, arch *archSpec) (uint16, error) {
+	if n, err := strconv.ParseUint(syscall, 10, 16); err == nil {
+		if _, exists := arch.systemCalls[uint16(n)]; exists {
+			return uint16(n), nil
+		}
+	}
+
+	for id, name := range arch.systemCalls {
+		if name == syscall {
+			return id, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown syscall for %s: %s", arch.name, syscall)
+}
+
+// findCallChains does a breadth-first search from all entry points, returning
+// the shortest call chain to each symbol which issues the system call.
+func findCallChains(symbols map[string]symbolDefinition, entryPoints []string, id uint16) []CallChain {
+	parents := make(map[string]string)
+	visited := make(map[string]bool)
+	queue := make([]string, 0, len(entryPoints))
+
+	for _, ep := range entryPoints {
+		if !visited[ep] {
+			visited[ep] = true
+			queue = append(queue, ep)
+		}
+	}
+
+	chains := make([]CallChain, 0)
+	for len(queue) > 0 {
+		symbol := queue[0]
+		queue = queue[1:]
+
+		s, found := symbols[symbol]
+		if !found {
+			continue
+		}
+
+		if containsID(s.syscallIDs, id) {
+			chains = append(chains, buildCallChain(parents, symbol))
+		}
+
+		for _, name := range s.subCalls {
+			if !visited[name] {
+				visited[name] = true
+				parents[name] = symbol
+				queue = append(queue, name)
+			}
+		}
+	}
+
+	sort.SliceStable(chains, func(i, j int) bool {
+		return len(chains[i]) < len(chains[j])
+	})
+
+	return chains
+}
+
+func buildCallChain(parents map[string]string, symbol string) CallChain {
+	chain := CallChain{symbol}
+	for parent, found := parents[symbol]; found; parent, found = parents[parent] {
+		chain = append(chain, parent)
+	}
+
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+
+	return chain
+}
+
+func containsID(ids []uint16, id uint16) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,76 @@
+package systract
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestWhy_E2E(t *testing.T) {
+	assertThat := func(assumption, fileName, syscall string, expected []CallChain, expectedErr bool) {
+		should := should.New(t)
+		filePath, _ := filepath.Abs(fileName)
+
+		actual, err := Why(NewDumpReader(filePath), syscall)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should find syscall by name", "../../test/single-syscall.dump", "exit_group",
+		[]CallChain{{"main.main"}}, false)
+	assertThat("should find syscall by id", "../../test/single-syscall.dump", "231",
+		[]CallChain{{"main.main"}}, false)
+	assertThat("should resolve names based on the architecture", "../../test/arm64-single-syscall.dump", "94",
+		[]CallChain{{"main.main"}}, false)
+	assertThat("should return no chains for syscalls not reachable", "../../test/single-syscall.dump", "write",
+		[]CallChain{}, false)
+	assertThat("should error for unknown syscalls", "../../test/single-syscall.dump", "not_a_syscall",
+		nil, true)
+	assertThat("should error when input file does not exist", "/tmp/3216763872163876321", "write",
+		nil, true)
+}
+
+func TestFindCallChains(t *testing.T) {
+	symbols := map[string]symbolDefinition{
+		"main.main":       {subCalls: []string{"main.run", "os.Exit"}},
+		"main.run":        {subCalls: []string{"pkg.Do"}},
+		"pkg.Do":          {subCalls: []string{"syscall.Syscall", "os.Exit"}},
+		"os.Exit":         {subCalls: []string{"runtime.exit"}},
+		"runtime.exit":    {syscallIDs: []uint16{231}},
+		"syscall.Syscall": {syscallIDs: []uint16{1, 231}},
+		"pkg.init":        {subCalls: []string{"syscall.Syscall"}},
+	}
+
+	assertThat := func(assumption string, entryPoints []string, id uint16, expected []CallChain) {
+		should := should.New(t)
+
+		actual := findCallChains(symbols, entryPoints, id)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should return shortest chain to each symbol, shortest first",
+		[]string{"main.main"}, 231, []CallChain{
+			{"main.main", "os.Exit", "runtime.exit"},
+			{"main.main", "main.run", "pkg.Do", "syscall.Syscall"},
+		})
+	assertThat("should search from all entry points",
+		[]string{"main.main", "pkg.init"}, 1, []CallChain{
+			{"pkg.init", "syscall.Syscall"},
+		})
+	assertThat("should return empty when syscall is not reachable",
+		[]string{"main.main"}, 59, []CallChain{})
+	assertThat("should ignore entry points not found",
+		[]string{"main.init.0"}, 1, []CallChain{})
+}
+
+func TestCallChainString(t *testing.T) {
+	should := should.New(t)
+
+	actual := CallChain{"main.main", "os.Exit", "runtime.exit"}.String()
+
+	should.BeEqual("main.main -> os.Exit -> runtime.exit", actual, "should join symbols with arrows")
+}
@@ -0,0 +1,155 @@
+package systract
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// SyscallWrapper represents a function which issues the system call whose
+// number is passed to it as an argument.
+type SyscallWrapper struct {
+	// Name is the symbol name of the function, e.g. syscall.RawSyscall6.
+	Name string
+	// TrapArg is the zero-based position of the argument carrying the syscall number.
+	TrapArg int
+}
+
+// knownSyscallWrappers are the wrappers of the go standard library and golang.org/x/sys.
+var knownSyscallWrappers = []SyscallWrapper{
+	{Name: "syscall.Syscall"},
+	{Name: "syscall.Syscall6"},
+	{Name: "syscall.Syscall9"},
+	{Name: "syscall.RawSyscall"},
+	{Name: "syscall.RawSyscall6"},
+	{Name: "syscall.rawSyscallNoError"},
+	{Name: "syscall.RawSyscallNoError"},
+	{Name: "syscall.rawVforkSyscall"},
+	{Name: "syscall.runtime_doAllThreadsSyscall"},
+	{Name: "syscall.AllThreadsSyscall"},
+	{Name: "syscall.AllThreadsSyscall6"},
+	{Name: "golang.org/x/sys/unix.Syscall"},
+	{Name: "golang.org/x/sys/unix.Syscall6"},
+	{Name: "golang.org/x/sys/unix.RawSyscall"},
+	{Name: "golang.org/x/sys/unix.RawSyscall6"},
+	{Name: "golang.org/x/sys/unix.SyscallNoError"},
+	{Name: "golang.org/x/sys/unix.RawSyscallNoError"},
+	{Name: "runtime/internal/syscall.Syscall6"},
+	{Name: "internal/runtime/syscall.Syscall6"},
+	{Name: "internal/runtime/syscall/linux.Syscall6"},
+}
+
+var (
+	syscallWrappersMu sync.RWMutex
+	syscallWrappers   = newSyscallWrappers(knownSyscallWrappers)
+)
+
+func newSyscallWrappers(wrappers []SyscallWrapper) map[string]int {
+	m := make(map[string]int, len(wrappers))
+	for _, w := range wrappers {
+		m[w.Name] = w.TrapArg
+	}
+
+	return m
+}
+
+// WrapperSource wraps a source so that calls to the syscall wrappers provided are
+// handled as system calls when it is analysed, on top of the wrappers registered.
+type WrapperSource struct {
+	sourceWrapper
+	wrappers []SyscallWrapper
+}
+
+// NewWrapperSource initialises a new WrapperSource for the syscall wrappers provided.
+func NewWrapperSource(source SourceReader, wrappers []SyscallWrapper) *WrapperSource {
+	return &WrapperSource{sourceWrapper: sourceWrapper{source}, wrappers: wrappers}
+}
+
+// syscallWrappers returns the wrappers of the source, followed by those of the source wrapped.
+func (w *WrapperSource) syscallWrappers() []SyscallWrapper {
+	return append(append([]SyscallWrapper{}, w.wrappers...), w.sourceWrapper.syscallWrappers()...)
+}
+
+// wrapperSource is implemented by sources which tell the syscall wrappers of their analysis.
+type wrapperSource interface {
+	syscallWrappers() []SyscallWrapper
+}
+
+// getSyscallWrappers returns the syscall wrappers the source is analysed with, besides those
+// registered, sorted by name. Wrappers provided more than once keep their first trap argument.
+func getSyscallWrappers(source SourceReader) ([]SyscallWrapper, error) {
+	s, ok := source.(wrapperSource)
+	if !ok {
+		return nil, nil
+	}
+
+	unique := make(map[string]bool)
+	var wrappers []SyscallWrapper
+	for _, w := range s.syscallWrappers() {
+		if err := validateSyscallWrapper(w.Name, w.TrapArg); err != nil {
+			return nil, err
+		}
+		if !unique[w.Name] {
+			unique[w.Name] = true
+			wrappers = append(wrappers, w)
+		}
+	}
+	sort.Slice(wrappers, func(i, j int) bool {
+		return wrappers[i].Name < wrappers[j].Name
+	})
+
+	return wrappers, nil
+}
+
+// RegisterSyscallWrapper adds a function to the syscall wrappers known by default, so that
+// calls to it are handled as system calls in all analyses. This is useful for vendored or
+// internal helpers which receive the syscall number as an argument, at the zero-based
+// position trapArg. Use NewWrapperSource to add wrappers to a single analysis instead.
+func RegisterSyscallWrapper(name string, trapArg int) error {
+	if err := validateSyscallWrapper(name, trapArg); err != nil {
+		return err
+	}
+
+	syscallWrappersMu.Lock()
+	defer syscallWrappersMu.Unlock()
+	syscallWrappers[name] = trapArg
+
+	return nil
+}
+
+func validateSyscallWrapper(name string, trapArg int) error {
+	if name == "" {
+		return fmt.Errorf("syscall wrapper name cannot be empty")
+	}
+	if trapArg < 0 {
+		return fmt.Errorf("invalid trap argument for %s: %d", name, trapArg)
+	}
+
+	return nil
+}
+
+// getSyscallWrapper returns the position of the argument carrying the
+// syscall number when the symbol is a known syscall wrapper.
+func getSyscallWrapper(symbol string) (int, bool) {
+	syscallWrappersMu.RLock()
+	defer syscallWrappersMu.RUnlock()
+
+	trapArg, found := syscallWrappers[symbol]
+	return trapArg, found
+}
+
+// registeredSyscallWrappers returns the syscall wrappers known, sorted by name.
+func registeredSyscallWrappers() []SyscallWrapper {
+	syscallWrappersMu.RLock()
+	defer syscallWrappersMu.RUnlock()
+
+	wrappers := make([]SyscallWrapper, 0, len(syscallWrappers))
+	for name, trapArg := range syscallWrappers {
+		wrappers = append(wrappers, SyscallWrapper{Name: name, TrapArg: trapArg})
+	}
+	sort.Slice(wrappers, func(i, j int) bool {
+		return wrappers[i].Name < wrappers[j].Name
+	})
+
+	return wrappers
+}
@@ -0,0 +1,107 @@
+package systract
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestExtract_E2E_SyscallWrappers(t *testing.T) {
+	should := should.New(t)
+	filePath, _ := filepath.Abs("../../test/syscall-wrappers.dump")
+
+	actual, err := Extract(NewDumpReader(filePath))
+
+	should.NotError(err, "should extract syscalls")
+	should.HaveSameItems(keys([]SystemCall{{ID: 16, Name: "ioctl"}}), keys(actual),
+		"should use the trap argument of known wrappers")
+
+	actual, err = Extract(NewWrapperSource(NewDumpReader(filePath), []SyscallWrapper{{Name: "main.rawSyscall", TrapArg: 1}}))
+
+	should.NotError(err, "should extract syscalls")
+	should.HaveSameItems(keys([]SystemCall{{ID: 16, Name: "ioctl"}, {ID: 39, Name: "getpid"}}), keys(actual),
+		"should use the trap argument of wrappers provided")
+
+	actual, err = Extract(NewDumpReader(filePath))
+
+	should.NotError(err, "should extract syscalls")
+	should.HaveSameItems(keys([]SystemCall{{ID: 16, Name: "ioctl"}}), keys(actual),
+		"should not keep wrappers provided for other analyses")
+
+	err = RegisterSyscallWrapper("main.rawSyscall", 1)
+	should.NotError(err, "should register wrapper")
+	defer unregisterSyscallWrapper("main.rawSyscall")
+
+	actual, err = Extract(NewDumpReader(filePath))
+
+	should.NotError(err, "should extract syscalls")
+	should.HaveSameItems(keys([]SystemCall{{ID: 16, Name: "ioctl"}, {ID: 39, Name: "getpid"}}), keys(actual),
+		"should use the trap argument of registered wrappers")
+
+	_, err = Extract(NewWrapperSource(NewDumpReader(filePath), []SyscallWrapper{{Name: "main.rawSyscall", TrapArg: -1}}))
+	should.Error(err, "should error for invalid wrappers provided")
+}
+
+func TestRegisterSyscallWrapper(t *testing.T) {
+	assertThat := func(assumption, name string, trapArg int, expectedErr bool) {
+		should := should.New(t)
+
+		err := RegisterSyscallWrapper(name, trapArg)
+		defer unregisterSyscallWrapper(name)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+
+		actual, found := getSyscallWrapper(name)
+		should.BeEqual(!expectedErr, found, assumption)
+		if found {
+			should.BeEqual(trapArg, actual, assumption)
+		}
+	}
+
+	assertThat("should register wrappers", "example.com/pkg/sys.do", 2, false)
+	assertThat("should error for empty names", "", 0, true)
+	assertThat("should error for negative trap arguments", "example.com/pkg/sys.other", -1, true)
+}
+
+func TestGetSyscallWrapper(t *testing.T) {
+	assertThat := func(assumption, symbol string, expectedFound bool) {
+		should := should.New(t)
+
+		trapArg, found := getSyscallWrapper(symbol)
+
+		should.BeEqual(expectedFound, found, assumption)
+		should.BeEqual(0, trapArg, assumption)
+	}
+
+	assertThat("should know raw syscalls", "syscall.RawSyscall6", true)
+	assertThat("should know vfork syscalls", "syscall.rawVforkSyscall", true)
+	assertThat("should know all threads syscalls", "syscall.runtime_doAllThreadsSyscall", true)
+	assertThat("should know runtime syscalls", "internal/runtime/syscall.Syscall6", true)
+	assertThat("should know older runtime syscalls", "runtime/internal/syscall.Syscall6", true)
+	assertThat("should know golang.org/x/sys syscalls", "golang.org/x/sys/unix.RawSyscallNoError", true)
+	assertThat("should not match functions by prefix", "syscall.SyscallN", false)
+}
+
+func TestStackArgsSlot(t *testing.T) {
+	assertThat := func(assumption, arch string, pos int, expected string) {
+		should := should.New(t)
+
+		actual := archs[arch].stackArgs.slot(pos)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should return first amd64 argument", "amd64", 0, "0(SP)")
+	assertThat("should return amd64 arguments in hexadecimal", "amd64", 2, "0x10(SP)")
+	assertThat("should return 386 arguments", "386", 1, "0x4(SP)")
+	assertThat("should skip arm64 link register", "arm64", 0, "8(RSP)")
+	assertThat("should skip ppc64le fixed frame", "ppc64le", 1, "40(R1)")
+}
+
+func unregisterSyscallWrapper(name string) {
+	syscallWrappersMu.Lock()
+	defer syscallWrappersMu.Unlock()
+	delete(syscallWrappers, name)
+}
@@ -1,9 +1,9 @@
 module github.com/pjbgf/gosystract
 
-go 1.12
+go 1.25.0
 
 require (
-	github.com/golang-collections/collections v0.0.0-20130729185459-604e922904d3
 	github.com/pjbgf/go-test v0.2.3
 	github.com/pkg/errors v0.9.1
+	golang.org/x/arch v0.29.0
 )